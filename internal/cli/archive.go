@@ -0,0 +1,228 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const archiveFileName = "archive.jsonl"
+
+var errAlreadyDownloaded = errors.New("already downloaded")
+
+// archiveMu guards appends from concurrent jobs within one process.
+var archiveMu sync.Mutex
+
+type archiveEntry struct {
+	Key          string    `json:"key"`
+	Variant      string    `json:"variant"`
+	URL          string    `json:"url"`
+	Path         string    `json:"path,omitempty"`
+	Artist       string    `json:"artist,omitempty"`
+	Title        string    `json:"title,omitempty"`
+	DownloadedAt time.Time `json:"downloaded_at"`
+}
+
+type downloadArchive struct {
+	path    string
+	entries map[string]archiveEntry
+}
+
+func userDataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "ytcli"), nil
+	}
+	if runtime.GOOS != "darwin" && runtime.GOOS != "windows" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve home directory: %w", err)
+		}
+		return filepath.Join(home, ".local", "share", "ytcli"), nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve user config directory: %w", err)
+	}
+	return filepath.Join(dir, "ytcli"), nil
+}
+
+func archiveEntryID(key, variant string) string {
+	return key + "|" + variant
+}
+
+// archiveVariant distinguishes downloads of the same video that produce
+// different files, such as audio versus full video or different clips.
+func archiveVariant(cfg config) string {
+	variant := cfg.Mode
+	if cfg.Mode == "audio" && cfg.audioFormat() != "mp3" {
+		variant += ":" + cfg.audioFormat()
+	}
+	if cfg.Mode != "audio" && videoVariant(cfg) != "" {
+		variant += ":" + videoVariant(cfg)
+	}
+	if cfg.Start != "" || cfg.End != "" {
+		variant += "@" + cfg.Start + "-" + cfg.End
+	}
+	if cfg.Split {
+		variant += "+chapters"
+	}
+	if cfg.Subs != "" {
+		variant += "+subs"
+	}
+	if cfg.Normalize {
+		variant += "+normalized"
+	}
+	if cfg.ReplayGain {
+		variant += "+replaygain"
+	}
+	if cfg.hasEffects() {
+		variant += "+effects"
+	}
+	return variant
+}
+
+func openArchive() (*downloadArchive, error) {
+	dir, err := userDataDir()
+	if err != nil {
+		return nil, err
+	}
+	return loadArchive(filepath.Join(dir, archiveFileName))
+}
+
+// loadArchive reads an append-only JSON lines archive. Later lines win, so
+// re-downloads with --force update the recorded path. Unreadable lines are
+// ignored rather than failing the whole run.
+func loadArchive(path string) (*downloadArchive, error) {
+	archive := &downloadArchive{path: path, entries: map[string]archiveEntry{}}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return archive, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open download archive: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry archiveEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.Key == "" {
+			continue
+		}
+		archive.entries[archiveEntryID(entry.Key, entry.Variant)] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read download archive: %w", err)
+	}
+	return archive, nil
+}
+
+// lookup returns the recorded download for key and variant. Entries whose
+// file has since been deleted are treated as missing.
+func (a *downloadArchive) lookup(key, variant string) (archiveEntry, bool) {
+	entry, ok := a.entries[archiveEntryID(key, variant)]
+	if !ok {
+		return archiveEntry{}, false
+	}
+	if entry.Path != "" {
+		if _, err := os.Stat(entry.Path); err != nil {
+			return archiveEntry{}, false
+		}
+	}
+	return entry, true
+}
+
+func (a *downloadArchive) record(entry archiveEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode archive entry: %w", err)
+	}
+
+	archiveMu.Lock()
+	defer archiveMu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(a.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	f, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open download archive: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write download archive: %w", err)
+	}
+	a.entries[archiveEntryID(entry.Key, entry.Variant)] = entry
+	return nil
+}
+
+// youtubeVideoID extracts the video ID from common YouTube URL shapes so the
+// archive can be consulted without a network round trip.
+func youtubeVideoID(rawURL string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || !isYouTubeHost(u.Host) {
+		return "", false
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if strings.EqualFold(strings.TrimPrefix(u.Host, "www."), "youtu.be") {
+		if segments[0] != "" {
+			return segments[0], true
+		}
+		return "", false
+	}
+
+	if segments[0] == "watch" {
+		if id := u.Query().Get("v"); id != "" {
+			return id, true
+		}
+		return "", false
+	}
+	if len(segments) >= 2 {
+		switch segments[0] {
+		case "shorts", "live", "embed", "v":
+			return segments[1], true
+		}
+	}
+	return "", false
+}
+
+// resolveArchiveKey returns the "extractor id" key for url, matching the
+// format of yt-dlp's own --download-archive files.
+func resolveArchiveKey(ctx context.Context, ytDlpBinary, rawURL string) (string, error) {
+	if id, ok := youtubeVideoID(rawURL); ok {
+		return "youtube " + id, nil
+	}
+
+	cmd := runner.Command(
+		ctx,
+		ytDlpBinary,
+		"--skip-download",
+		"--no-warnings",
+		"--no-playlist",
+		"--print", "%(extractor_key)s %(id)s",
+		rawURL,
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve video id: %w", err)
+	}
+
+	fields := strings.Fields(strings.TrimSpace(string(out)))
+	if len(fields) != 2 || fields[1] == "NA" {
+		return "", fmt.Errorf("failed to resolve video id")
+	}
+	return strings.ToLower(fields[0]) + " " + fields[1], nil
+}
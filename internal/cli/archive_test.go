@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestYoutubeVideoID(t *testing.T) {
+	tests := []struct {
+		input  string
+		want   string
+		wantOK bool
+	}{
+		{input: "https://youtu.be/abc123?t=5", want: "abc123", wantOK: true},
+		{input: "https://www.youtube.com/watch?v=abc123&list=PL1", want: "abc123", wantOK: true},
+		{input: "https://www.youtube.com/shorts/abc123", want: "abc123", wantOK: true},
+		{input: "https://www.youtube.com/@artist", wantOK: false},
+		{input: "https://vimeo.com/12345", wantOK: false},
+	}
+
+	for _, tc := range tests {
+		got, ok := youtubeVideoID(tc.input)
+		if ok != tc.wantOK {
+			t.Fatalf("%s: ok got %v, want %v", tc.input, ok, tc.wantOK)
+		}
+		if ok && got != tc.want {
+			t.Fatalf("%s: got %q, want %q", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestDownloadArchiveRecordAndLookup(t *testing.T) {
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "nested", archiveFileName)
+	downloaded := filepath.Join(dir, "Daft Punk - One More Time.mp3")
+	if err := os.WriteFile(downloaded, []byte("audio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := loadArchive(archivePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := archive.lookup("youtube abc", "audio"); ok {
+		t.Fatal("did not expect entry in empty archive")
+	}
+
+	err = archive.record(archiveEntry{Key: "youtube abc", Variant: "audio", URL: "https://youtu.be/abc", Path: downloaded})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reloaded, err := loadArchive(archivePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entry, ok := reloaded.lookup("youtube abc", "audio")
+	if !ok {
+		t.Fatal("expected recorded entry to be found")
+	}
+	if entry.Path != downloaded {
+		t.Fatalf("path got %q, want %q", entry.Path, downloaded)
+	}
+	if _, ok := reloaded.lookup("youtube abc", "full"); ok {
+		t.Fatal("did not expect entry for a different variant")
+	}
+
+	if err := os.Remove(downloaded); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reloaded.lookup("youtube abc", "audio"); ok {
+		t.Fatal("did not expect entry whose file was deleted")
+	}
+}
+
+func TestArchiveVariant(t *testing.T) {
+	if got := archiveVariant(config{Mode: "audio"}); got != "audio" {
+		t.Fatalf("got %q, want %q", got, "audio")
+	}
+	got := archiveVariant(config{Mode: "full", Start: "00:00:30", End: "00:01:00"})
+	if got != "full@00:00:30-00:01:00" {
+		t.Fatalf("got %q", got)
+	}
+}
@@ -0,0 +1,284 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+type batchItem struct {
+	Line       int // line in the batch file, 0 when not read from a file
+	Index      int // playlist index, 0 when not expanded from a playlist
+	Part       int // clip number, 0 when not expanded from --clip
+	URL        string
+	Clip       *clipRange
+	SkipReason string
+	Err        error
+}
+
+func (item batchItem) label() string {
+	label := item.sourceLabel()
+	if item.Part > 0 {
+		if label == "-" {
+			return fmt.Sprintf("clip %d", item.Part)
+		}
+		return fmt.Sprintf("%s clip %d", label, item.Part)
+	}
+	return label
+}
+
+func (item batchItem) sourceLabel() string {
+	switch {
+	case item.Line > 0 && item.Index > 0:
+		return fmt.Sprintf("line %d #%d", item.Line, item.Index)
+	case item.Line > 0:
+		return fmt.Sprintf("line %d", item.Line)
+	case item.Index > 0:
+		return fmt.Sprintf("#%d", item.Index)
+	}
+	return "-"
+}
+
+type batchResult struct {
+	Item   batchItem
+	Status resultStatus
+	Detail string
+	Result *downloadResult
+}
+
+func parseBatchList(r io.Reader) ([]batchItem, error) {
+	items := []batchItem{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		items = append(items, batchItem{Line: lineNo, URL: line})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func readBatchFile(path string, stdin io.Reader) ([]batchItem, error) {
+	var r io.Reader
+	if path == "-" {
+		r = stdin
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open batch file: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	items, err := parseBatchList(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch file: %w", err)
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("batch file contains no urls")
+	}
+	return items, nil
+}
+
+// prefixWriter prefixes every complete line written to it and serializes
+// writes through a mutex shared by all jobs, so concurrent yt-dlp output
+// never interleaves mid-line.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix string
+	buf    []byte
+}
+
+func newPrefixWriter(mu *sync.Mutex, w io.Writer, prefix string) *prefixWriter {
+	return &prefixWriter{mu: mu, w: w, prefix: prefix}
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if _, err := fmt.Fprintf(p.w, "%s%s", p.prefix, p.buf[:i+1]); err != nil {
+			return len(b), err
+		}
+		p.buf = p.buf[i+1:]
+	}
+	return len(b), nil
+}
+
+// Flush writes any trailing partial line.
+func (p *prefixWriter) Flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.buf) > 0 {
+		fmt.Fprintf(p.w, "%s%s\n", p.prefix, p.buf)
+		p.buf = nil
+	}
+}
+
+func runBatch(ctx context.Context, cfg config, items []batchItem, stdout, stderr io.Writer) []batchResult {
+	results := make([]batchResult, len(items))
+	pending := []int{}
+	seen := map[string]string{}
+
+	for i, item := range items {
+		itemCfg := item.config(cfg)
+
+		switch {
+		case item.Err != nil:
+			fmt.Fprintf(stderr, "Error: %v\n", item.Err)
+			results[i] = batchResult{
+				Item:   item,
+				Status: statusFailed,
+				Detail: item.Err.Error(),
+				Result: failedResult(itemCfg, withKind(errKindDownload, item.Err)),
+			}
+		case item.SkipReason != "":
+			results[i] = batchResult{
+				Item:   item,
+				Status: statusSkipped,
+				Detail: item.SkipReason,
+				Result: skippedResult(itemCfg, item.SkipReason),
+			}
+		default:
+			key := item.URL
+			if item.Clip != nil {
+				key += "|" + item.Clip.Start + "-" + item.Clip.End
+			}
+			if first, ok := seen[key]; ok {
+				detail := "duplicate of " + first
+				results[i] = batchResult{Item: item, Status: statusSkipped, Detail: detail, Result: skippedResult(itemCfg, detail)}
+				continue
+			}
+			seen[key] = item.label()
+			pending = append(pending, i)
+		}
+	}
+
+	jobs := cfg.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+	if jobs > len(pending) {
+		jobs = len(pending)
+	}
+
+	var outMu sync.Mutex
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				// Items still queued after an interrupt are not started.
+				if ctx.Err() != nil {
+					reason := errInterrupted.Error()
+					results[i] = batchResult{Item: items[i], Status: statusSkipped, Detail: reason, Result: skippedResult(items[i].config(cfg), reason)}
+					continue
+				}
+				results[i] = runBatchItem(ctx, cfg, items[i], i, len(items), jobs > 1, &outMu, stdout, stderr)
+			}
+		}()
+	}
+	for _, i := range pending {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	return results
+}
+
+// config returns the per-item configuration derived from the shared one.
+func (item batchItem) config(cfg config) config {
+	itemCfg := cfg
+	itemCfg.URL = item.URL
+	itemCfg.Clips = nil
+	if item.Clip != nil {
+		itemCfg.Start = item.Clip.Start
+		itemCfg.End = item.Clip.End
+		itemCfg.ClipLabel = item.Clip.Label
+	}
+	return itemCfg
+}
+
+func runBatchItem(ctx context.Context, cfg config, item batchItem, i, total int, parallel bool, outMu *sync.Mutex, stdout, stderr io.Writer) batchResult {
+	itemCfg := item.config(cfg)
+
+	prefix := fmt.Sprintf("[%d/%d] ", i+1, total)
+	itemStdout, itemStderr := stdout, stderr
+	if parallel {
+		prefixedStdout := newPrefixWriter(outMu, stdout, prefix)
+		prefixedStderr := newPrefixWriter(outMu, stderr, prefix)
+		defer prefixedStdout.Flush()
+		defer prefixedStderr.Flush()
+		itemStdout, itemStderr = prefixedStdout, prefixedStderr
+		prefix = ""
+	}
+
+	fmt.Fprintf(itemStdout, "%s%s\n", prefix, item.URL)
+	result, err := run(ctx, itemCfg, itemStdout, itemStderr)
+	if err != nil {
+		if errors.Is(err, errAlreadyDownloaded) {
+			fmt.Fprintf(itemStdout, "Skipping: %v\n", err)
+			return batchResult{Item: item, Status: statusSkipped, Detail: err.Error(), Result: result}
+		}
+		fmt.Fprintf(itemStderr, "Error: %v\n", err)
+		return batchResult{Item: item, Status: statusFailed, Detail: err.Error(), Result: result}
+	}
+	return batchResult{Item: item, Status: statusSucceeded, Result: result}
+}
+
+func batchHasFailures(results []batchResult) bool {
+	for _, r := range results {
+		if r.Status == statusFailed {
+			return true
+		}
+	}
+	return false
+}
+
+func writeBatchSummary(w io.Writer, results []batchResult) {
+	counts := map[resultStatus]int{}
+	for _, r := range results {
+		counts[r.Status]++
+	}
+
+	fmt.Fprintf(
+		w,
+		"\nBatch summary: %d succeeded, %d skipped, %d failed\n",
+		counts[statusSucceeded],
+		counts[statusSkipped],
+		counts[statusFailed],
+	)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ITEM\tSTATUS\tURL\tDETAIL")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Item.label(), r.Status, r.Item.URL, r.Detail)
+	}
+	tw.Flush()
+}
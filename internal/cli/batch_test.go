@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseBatchListSkipsBlankAndCommentLines(t *testing.T) {
+	input := "https://youtu.be/one\n\n  # comment\n  https://youtu.be/two  \n"
+
+	items, err := parseBatchList(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("got %d items, want 2", len(items))
+	}
+	if items[0].Line != 1 || items[0].URL != "https://youtu.be/one" {
+		t.Fatalf("unexpected first item: %+v", items[0])
+	}
+	if items[1].Line != 4 || items[1].URL != "https://youtu.be/two" {
+		t.Fatalf("unexpected second item: %+v", items[1])
+	}
+}
+
+func TestReadBatchFileRejectsEmptyList(t *testing.T) {
+	_, err := readBatchFile("-", strings.NewReader("# nothing here\n"))
+	if err == nil {
+		t.Fatal("expected error for empty batch list")
+	}
+}
+
+func TestWriteBatchSummary(t *testing.T) {
+	results := []batchResult{
+		{Item: batchItem{Line: 1, URL: "https://youtu.be/one"}, Status: statusSucceeded},
+		{Item: batchItem{Line: 2, URL: "https://youtu.be/one"}, Status: statusSkipped, Detail: "duplicate of line 1"},
+		{Item: batchItem{Line: 3, URL: "https://youtu.be/two"}, Status: statusFailed, Detail: "download failed: exit status 1"},
+	}
+
+	var buf bytes.Buffer
+	writeBatchSummary(&buf, results)
+	out := buf.String()
+
+	if !strings.Contains(out, "Batch summary: 1 succeeded, 1 skipped, 1 failed") {
+		t.Fatalf("missing summary counts in output:\n%s", out)
+	}
+	if !strings.Contains(out, "download failed: exit status 1") {
+		t.Fatalf("missing failure detail in output:\n%s", out)
+	}
+	if !batchHasFailures(results) {
+		t.Fatal("expected failures to be reported")
+	}
+}
+
+func TestParseConfigBatchFile(t *testing.T) {
+	cfg, _, err := parseConfig([]string{"--batch-file", "urls.txt"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.BatchFile != "urls.txt" {
+		t.Fatalf("batch file got %q, want %q", cfg.BatchFile, "urls.txt")
+	}
+
+	_, _, err = parseConfig([]string{"--batch-file", "urls.txt", "https://youtu.be/example"}, &bytes.Buffer{})
+	if err == nil {
+		t.Fatal("expected error when combining --batch-file with a url")
+	}
+}
+
+func TestPrefixWriterPrefixesCompleteLines(t *testing.T) {
+	var buf bytes.Buffer
+	var mu sync.Mutex
+	w := newPrefixWriter(&mu, &buf, "[1/2] ")
+
+	w.Write([]byte("first line\nsecond "))
+	w.Write([]byte("line\npartial"))
+	w.Flush()
+
+	want := "[1/2] first line\n[1/2] second line\n[1/2] partial\n"
+	if buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestParseConfigRejectsInvalidJobs(t *testing.T) {
+	_, _, err := parseConfig([]string{"--jobs", "0", "--batch-file", "urls.txt"}, &bytes.Buffer{})
+	if err == nil {
+		t.Fatal("expected error for --jobs 0")
+	}
+}
@@ -0,0 +1,203 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// videoChapter is one entry of the "chapters" list in yt-dlp's info JSON.
+type videoChapter struct {
+	Title string  `json:"title"`
+	Start float64 `json:"start_time"`
+	End   float64 `json:"end_time"`
+}
+
+// chapterAlbumArtist picks the album artist for a chapter split: the manual
+// --artist override, then the resolved artist of the whole video.
+func chapterAlbumArtist(info *videoInfo, artistOverride string) string {
+	if strings.TrimSpace(artistOverride) != "" {
+		return cleanArtist(artistOverride)
+	}
+	artist := info.Artist
+	if strings.TrimSpace(artist) == "" {
+		artist = info.uploaderName()
+	}
+	return cleanArtist(artist)
+}
+
+// chapterAlbum is the album of a chapter split: the --album override or the
+// video title.
+func chapterAlbum(info *videoInfo, albumOverride string) string {
+	if strings.TrimSpace(albumOverride) != "" {
+		return strings.TrimSpace(albumOverride)
+	}
+	return cleanTitle(info.Title)
+}
+
+// chapterTrackMetadata resolves the tags for chapter i (0-based) of info.
+// Chapter titles without an artist fall back to the album artist; date,
+// genre and source url are those of the whole video.
+func chapterTrackMetadata(info *videoInfo, i int, album, albumArtist string) trackMetadata {
+	meta := parseTrackMetadata(info.Chapters[i].Title, albumArtist)
+	if meta.Title == "" {
+		meta.Title = fmt.Sprintf("Chapter %d", i+1)
+	}
+	meta.Album = album
+	meta.AlbumArtist = albumArtist
+	meta.Track = i + 1
+	meta.TrackTotal = len(info.Chapters)
+	meta.Date = info.releaseDate()
+	meta.Genre = info.genre()
+	meta.Comment = info.WebpageURL
+	return meta
+}
+
+// chapterFileName names a chapter file after its track number, zero-padded
+// to the width of the track total so the files sort in order.
+func chapterFileName(mode string, meta trackMetadata, ext string) string {
+	width := len(strconv.Itoa(meta.TrackTotal))
+	if width < 2 {
+		width = 2
+	}
+	name := sanitizeFilenamePart(meta.Title)
+	if mode == "audio" {
+		name = sanitizeFilenamePart(meta.Artist) + " - " + name
+	}
+	return fmt.Sprintf("%0*d - %s%s", width, meta.Track, name, ext)
+}
+
+// chapterDir is the directory that receives the chapter files of path: a
+// folder named after the album next to the downloaded file.
+func chapterDir(path, album string) string {
+	return filepath.Join(filepath.Dir(path), sanitizeFilenamePart(album))
+}
+
+func formatSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', 3, 64)
+}
+
+// extractChapter copies one chapter of path into out without re-encoding and
+// writes its tags, and the cover when non-nil, in the same ffmpeg pass. The
+// chapter is selected with input options so the cover input is not seeked.
+func extractChapter(ctx context.Context, path, out string, chapter videoChapter, meta trackMetadata, cover *coverArt) error {
+	cmd := runner.Command(ctx, "ffmpeg", chapterArgs(path, out, chapter, meta, cover)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		os.Remove(out)
+		message := strings.TrimSpace(string(output))
+		if message == "" {
+			message = err.Error()
+		}
+		return fmt.Errorf("ffmpeg chapter split failed: %s", message)
+	}
+	return nil
+}
+
+// chapterArgs returns the ffmpeg arguments extractChapter runs.
+func chapterArgs(path, out string, chapter videoChapter, meta trackMetadata, cover *coverArt) []string {
+	args := []string{
+		"-hide_banner",
+		"-loglevel", "error",
+		"-nostdin",
+		"-y",
+		"-ss", formatSeconds(chapter.Start),
+	}
+	if chapter.End > chapter.Start {
+		args = append(args, "-t", formatSeconds(chapter.End-chapter.Start))
+	}
+	args = append(args, "-i", path)
+	if cover != nil {
+		args = append(args, "-i", cover.Path)
+		args = append(args, coverArgs(*cover)...)
+	} else {
+		args = append(args, "-map", "0", "-c", "copy")
+	}
+	args = append(args, "-map_chapters", "-1")
+	args = append(args, metadataArgs(meta, filepath.Ext(out))...)
+	return append(args, out)
+}
+
+// splitChaptersStep replaces the download with its chapter files. From here
+// on the job stands for the chapter folder, tagged as the album.
+func splitChaptersStep(ctx context.Context, job *postJob) error {
+	if strings.TrimSpace(job.Path) == "" {
+		return withKind(errKindPostprocess, fmt.Errorf("download completed but could not determine output path for chapter split"))
+	}
+	dir, files, err := splitChapters(ctx, job.Path, job.Info, job.Cfg, job.Cover, job.Stdout)
+	job.Result.Files = files
+	if err != nil {
+		return withKind(errKindPostprocess, err)
+	}
+	if err := os.Remove(job.Path); err != nil {
+		job.warn("failed to remove unsplit download (%v)", err)
+	}
+	job.Path = dir
+	job.Files = files
+	job.Result.Path = dir
+	job.Meta = &trackMetadata{
+		Artist: chapterAlbumArtist(job.Info, job.Cfg.Artist),
+		Title:  chapterAlbum(job.Info, job.Cfg.Album),
+	}
+	return nil
+}
+
+// splitChapters writes one tagged file per chapter of info into a folder
+// named after the album. It returns the directory and the chapter files in
+// order; the unsplit download is left for the caller to remove.
+func splitChapters(ctx context.Context, path string, info *videoInfo, cfg config, cover *coverArt, stdout io.Writer) (string, []string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to resolve downloaded file path: %w", err)
+	}
+	if _, err := os.Stat(absPath); err != nil {
+		return "", nil, fmt.Errorf("downloaded file not found for chapter split: %w", err)
+	}
+
+	dir, parts := planChapters(absPath, info, cfg)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", nil, fmt.Errorf("failed to create chapter directory: %w", err)
+	}
+
+	files := make([]string, 0, len(parts))
+	for _, part := range parts {
+		meta := part.Meta
+		if err := extractChapter(ctx, absPath, part.Out, part.Chapter, meta, cover); err != nil {
+			return dir, files, fmt.Errorf("chapter %d/%d (%s): %w", meta.Track, meta.TrackTotal, part.Chapter.Title, err)
+		}
+		files = append(files, part.Out)
+		fmt.Fprintf(stdout, "Split chapter %d/%d: %s\n", meta.Track, meta.TrackTotal, filepath.Base(part.Out))
+	}
+	return dir, files, nil
+}
+
+// chapterPart is one file a chapter split of a download produces.
+type chapterPart struct {
+	Chapter videoChapter
+	Meta    trackMetadata
+	Out     string
+}
+
+// planChapters resolves the folder, tags and file name of every chapter of
+// info for the download at path, without touching the filesystem.
+func planChapters(path string, info *videoInfo, cfg config) (string, []chapterPart) {
+	album := chapterAlbum(info, cfg.Album)
+	albumArtist := chapterAlbumArtist(info, cfg.Artist)
+	dir := chapterDir(path, album)
+
+	ext := filepath.Ext(path)
+	parts := make([]chapterPart, 0, len(info.Chapters))
+	for i, chapter := range info.Chapters {
+		meta := chapterTrackMetadata(info, i, album, albumArtist)
+		if meta.Comment == "" {
+			meta.Comment = cfg.URL
+		}
+		applyTagOverrides(&meta, "", cfg.Year, cfg.Genre)
+		parts = append(parts, chapterPart{Chapter: chapter, Meta: meta, Out: filepath.Join(dir, chapterFileName(cfg.Mode, meta, ext))})
+	}
+	return dir, parts
+}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const chapteredInfoJSON = `{
+	"id": "set123",
+	"title": "Boiler Room Set (Full Album)",
+	"uploader": "Some DJ - Topic",
+	"duration": 600,
+	"upload_date": "20240105",
+	"webpage_url": "https://www.youtube.com/watch?v=set123",
+	"chapters": [
+		{"title": "Intro", "start_time": 0, "end_time": 60},
+		{"title": "Daft Punk - One More Time", "start_time": 60, "end_time": 300},
+		{"title": "Around the World by Daft Punk", "start_time": 300, "end_time": 600}
+	]
+}`
+
+func TestChapterTrackMetadata(t *testing.T) {
+	info, err := parseVideoInfo([]byte(chapteredInfoJSON))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(info.Chapters) != 3 || info.Chapters[1].Start != 60 || info.Chapters[1].End != 300 {
+		t.Fatalf("unexpected chapters: %+v", info.Chapters)
+	}
+
+	albumArtist := chapterAlbumArtist(info, "")
+	if albumArtist != "Some DJ" {
+		t.Fatalf("got album artist %q", albumArtist)
+	}
+	if got := chapterAlbumArtist(info, "  Various Artists "); got != "Various Artists" {
+		t.Fatalf("artist override ignored, got %q", got)
+	}
+	if got := chapterAlbum(info, "Live at Berghain"); got != "Live at Berghain" {
+		t.Fatalf("album override ignored, got %q", got)
+	}
+
+	tests := []struct {
+		index int
+		want  trackMetadata
+	}{
+		{0, trackMetadata{Artist: "Some DJ", Title: "Intro"}},
+		{1, trackMetadata{Artist: "Daft Punk", Title: "One More Time"}},
+		{2, trackMetadata{Artist: "Daft Punk", Title: "Around the World"}},
+	}
+	for _, tc := range tests {
+		tc.want.Album = "Boiler Room Set (Full Album)"
+		tc.want.AlbumArtist = "Some DJ"
+		tc.want.Track = tc.index + 1
+		tc.want.TrackTotal = 3
+		tc.want.Date = "2024-01-05"
+		tc.want.Comment = "https://www.youtube.com/watch?v=set123"
+		if got := chapterTrackMetadata(info, tc.index, chapterAlbum(info, ""), albumArtist); got != tc.want {
+			t.Fatalf("chapter %d: got %+v, want %+v", tc.index, got, tc.want)
+		}
+	}
+}
+
+func TestChapterFileName(t *testing.T) {
+	meta := trackMetadata{Artist: "Daft Punk", Title: "One/More Time", Track: 2, TrackTotal: 12}
+	if got := chapterFileName("audio", meta, ".mp3"); got != "02 - Daft Punk - One-More Time.mp3" {
+		t.Fatalf("got %q", got)
+	}
+	if got := chapterFileName("video", meta, ".mp4"); got != "02 - One-More Time.mp4" {
+		t.Fatalf("got %q", got)
+	}
+	meta.Track, meta.TrackTotal = 7, 120
+	if got := chapterFileName("audio", meta, ".mp3"); got != "007 - Daft Punk - One-More Time.mp3" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestParseConfigSplitChapters(t *testing.T) {
+	cfg, _, err := parseConfig([]string{"--split-chapters", "--mode", "audio", "https://youtu.be/example"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Split || archiveVariant(cfg) != "audio+chapters" {
+		t.Fatalf("unexpected config: %+v (variant %q)", cfg, archiveVariant(cfg))
+	}
+
+	_, _, err = parseConfig([]string{"--split-chapters", "--start", "0:10", "https://youtu.be/example"}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "cannot be combined") {
+		t.Fatalf("expected combination error, got %v", err)
+	}
+
+	_, _, err = parseConfig([]string{"--split-chapters", "--mode", "audio", "--song", "Title", "https://youtu.be/example"}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "--song") {
+		t.Fatalf("expected --song error, got %v", err)
+	}
+}
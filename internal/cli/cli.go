@@ -2,79 +2,184 @@ package cli
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"math"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/CoastalFuturist/ytcli/internal/buildinfo"
 )
 
 var (
-	reMMSS   = regexp.MustCompile(`^([0-5]?\d):([0-5]?\d)$`)
-	reHHMMSS = regexp.MustCompile(`^(\d+):([0-5]?\d):([0-5]?\d)$`)
-	reNoise  = regexp.MustCompile(`(?i)\s*[\(\[\{][^)\]}]*(official|lyrics?|audio|video|visualizer|mv|hq|hd|4k)[^)\]}]*[\)\]\}]\s*$`)
-	reBy     = regexp.MustCompile(`(?i)^(.+?)\s+by\s+(.+)$`)
+	reMMSS    = regexp.MustCompile(`^([0-5]?\d):([0-5]?\d(?:\.\d+)?)$`)
+	reHHMMSS  = regexp.MustCompile(`^(\d+):([0-5]?\d):([0-5]?\d(?:\.\d+)?)$`)
+	reSeconds = regexp.MustCompile(`^\d+(?:\.\d+)?$`)
+	reUnits   = regexp.MustCompile(`^(?:(\d+(?:\.\d+)?)h)?(?:(\d+(?:\.\d+)?)m)?(?:(\d+(?:\.\d+)?)s)?$`)
+	reNoise   = regexp.MustCompile(`(?i)\s*[\(\[\{][^)\]}]*(official|lyrics?|audio|video|visualizer|mv|hq|hd|4k)[^)\]}]*[\)\]\}]\s*$`)
+	reBy      = regexp.MustCompile(`(?i)^(.+?)\s+by\s+(.+)$`)
+
+	// reTitleField matches output templates that already carry the clip
+	// suffix through a title or track field.
+	reTitleField = regexp.MustCompile(`%\([^)]*\b(title|track)\b`)
 )
 
-const finalPathPrefix = "__YTCLI_FINAL_PATH__:"
+const (
+	finalPathPrefix = "__YTCLI_FINAL_PATH__:"
+	thumbnailPrefix = "__YTCLI_THUMBNAIL__:"
+)
 
 type config struct {
-	URL         string
-	Start       string
-	End         string
-	Mode        string
-	Output      string
-	Artist      string
-	Song        string
-	AppleMusic  bool
-	ShowVersion bool
+	URL              string
+	Start            string
+	End              string
+	Duration         string
+	Clips            []clipRange
+	ClipLabel        string
+	Mode             string
+	AudioFormat      string
+	MaxHeight        int
+	FPS              int
+	VCodec           string
+	Container        string
+	Subs             string
+	AutoSubs         bool
+	EmbedSubs        bool
+	Output           string
+	Artist           string
+	Song             string
+	Album            string
+	Year             string
+	Genre            string
+	AppleMusic       bool
+	Cover            string
+	NoCover          bool
+	SquareCover      bool
+	Normalize        bool
+	TargetLUFS       float64
+	ReplayGain       bool
+	TrimSilence      bool
+	SilenceThreshold float64
+	FadeIn           string
+	FadeOut          string
+	BatchFile        string
+	Items            string
+	ItemRanges       []itemRange
+	Reverse          bool
+	Split            bool
+	Jobs             int
+	NoArchive        bool
+	Force            bool
+	ConfigPath       string
+	Profile          string
+	JSON             bool
+	DryRun           bool
+	ShowVersion      bool
 }
 
 type trackMetadata struct {
-	Artist string
-	Title  string
+	Artist      string `json:"artist"`
+	Title       string `json:"title"`
+	Album       string `json:"album,omitempty"`
+	AlbumArtist string `json:"album_artist,omitempty"`
+	Track       int    `json:"track,omitempty"`
+	TrackTotal  int    `json:"track_total,omitempty"`
+	Date        string `json:"date,omitempty"`
+	Genre       string `json:"genre,omitempty"`
+	Comment     string `json:"comment,omitempty"`
 }
 
+// normalizeTimestamp accepts MM:SS, HH:MM:SS, plain seconds and unit forms
+// such as 1h2m3s, each with optional fractional seconds, and returns
+// HH:MM:SS[.fff]. A leading "-" marks an offset from the end of the video,
+// which is kept until the duration is known.
 func normalizeTimestamp(value string) (string, error) {
 	value = strings.TrimSpace(value)
 	if value == "" {
 		return "", nil
 	}
 
+	body := strings.TrimPrefix(value, "-")
+	seconds, ok := parseTimestampSeconds(body)
+	if !ok {
+		return "", fmt.Errorf("invalid timestamp %q; use MM:SS, HH:MM:SS, seconds or 1h2m3s (fractions allowed)", value)
+	}
+	if body != value {
+		if seconds == 0 {
+			return "", fmt.Errorf("invalid timestamp %q; an offset from the end must be greater than zero", value)
+		}
+		return "-" + formatTimestamp(seconds), nil
+	}
+	return formatTimestamp(seconds), nil
+}
+
+func parseTimestampSeconds(value string) (float64, bool) {
 	if m := reMMSS.FindStringSubmatch(value); m != nil {
-		minutes, _ := strconv.Atoi(m[1])
-		seconds, _ := strconv.Atoi(m[2])
-		return fmt.Sprintf("%02d:%02d:%02d", 0, minutes, seconds), nil
+		minutes, _ := strconv.ParseFloat(m[1], 64)
+		seconds, _ := strconv.ParseFloat(m[2], 64)
+		return minutes*60 + seconds, true
 	}
 
 	if m := reHHMMSS.FindStringSubmatch(value); m != nil {
-		hours, _ := strconv.Atoi(m[1])
-		minutes, _ := strconv.Atoi(m[2])
-		seconds, _ := strconv.Atoi(m[3])
-		return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds), nil
+		hours, _ := strconv.ParseFloat(m[1], 64)
+		minutes, _ := strconv.ParseFloat(m[2], 64)
+		seconds, _ := strconv.ParseFloat(m[3], 64)
+		return hours*3600 + minutes*60 + seconds, true
+	}
+
+	if reSeconds.MatchString(value) {
+		seconds, _ := strconv.ParseFloat(value, 64)
+		return seconds, true
 	}
 
-	return "", fmt.Errorf("invalid timestamp %q; use MM:SS or HH:MM:SS", value)
+	if m := reUnits.FindStringSubmatch(value); m != nil && value != "" {
+		total := 0.0
+		for i, scale := range []float64{3600, 60, 1} {
+			if m[i+1] != "" {
+				n, _ := strconv.ParseFloat(m[i+1], 64)
+				total += n * scale
+			}
+		}
+		return total, true
+	}
+
+	return 0, false
 }
 
-func timestampToSeconds(ts string) int {
-	parts := strings.Split(ts, ":")
-	if len(parts) != 3 {
-		return 0
+// formatTimestamp renders seconds as HH:MM:SS, adding up to millisecond
+// precision only when the value has a fractional part.
+func formatTimestamp(seconds float64) string {
+	ms := int64(math.Round(seconds * 1000))
+	ts := fmt.Sprintf("%02d:%02d:%02d", ms/3600000, ms/60000%60, ms/1000%60)
+	if frac := ms % 1000; frac != 0 {
+		ts += strings.TrimRight(fmt.Sprintf(".%03d", frac), "0")
 	}
+	return ts
+}
 
-	hours, _ := strconv.Atoi(parts[0])
-	minutes, _ := strconv.Atoi(parts[1])
-	seconds, _ := strconv.Atoi(parts[2])
-	return hours*3600 + minutes*60 + seconds
+// isEndOffset reports whether a normalized timestamp counts back from the
+// end of the video.
+func isEndOffset(ts string) bool {
+	return strings.HasPrefix(ts, "-")
+}
+
+// timestampToSeconds converts a normalized timestamp to seconds; end offsets
+// are negative.
+func timestampToSeconds(ts string) float64 {
+	seconds, _ := parseTimestampSeconds(strings.TrimPrefix(ts, "-"))
+	if isEndOffset(ts) {
+		return -seconds
+	}
+	return seconds
 }
 
 func sanitizeFilenamePart(value string) string {
@@ -202,6 +307,9 @@ func outputTemplate(output string, cfg config, meta *trackMetadata) (string, err
 		return filepath.Join(expanded, defaultTemplate), nil
 	}
 
+	if cfg.ClipLabel != "" && !reTitleField.MatchString(expanded) {
+		return withClipSuffix(expanded, cfg.ClipLabel), nil
+	}
 	return expanded, nil
 }
 
@@ -210,15 +318,17 @@ func buildArgs(cfg config, meta *trackMetadata) ([]string, error) {
 
 	switch cfg.Mode {
 	case "audio":
-		args = append(args, "-x", "--audio-format", "mp3", "--audio-quality", "0")
-	case "video":
-		args = append(args, "-f", "bv*[ext=mp4]/bv*", "--recode-video", "mp4")
-	case "full":
-		args = append(args, "-f", "bv*+ba/b", "--merge-output-format", "mp4")
+		args = append(args, "-x", "--audio-format", cfg.audioFormat(), "--audio-quality", "0")
+	case "video", "full":
+		args = append(args, videoFormatArgs(cfg)...)
 	default:
 		return nil, fmt.Errorf("invalid mode %q; expected audio, video, or full", cfg.Mode)
 	}
 
+	args = append(args, progressArgs()...)
+	args = append(args, thumbnailArgs(cfg)...)
+	args = append(args, subtitleArgs(cfg)...)
+
 	if cfg.Start != "" || cfg.End != "" {
 		start := cfg.Start
 		if start == "" {
@@ -228,6 +338,16 @@ func buildArgs(cfg config, meta *trackMetadata) ([]string, error) {
 		args = append(args, "--download-sections", section)
 	}
 
+	if cfg.ClipLabel != "" {
+		// Appending to the title field gives yt-dlp's own templates and
+		// embedded tags the clip suffix; "$" matches the end of the value.
+		suffix := strings.ReplaceAll(clipSuffix(cfg.ClipLabel), `\`, `\\`)
+		args = append(args, "--replace-in-metadata", "title,track", "$", suffix)
+		if cfg.Mode != "audio" {
+			args = append(args, "--embed-metadata")
+		}
+	}
+
 	if cfg.Output != "" {
 		template, err := outputTemplate(cfg.Output, cfg, meta)
 		if err != nil {
@@ -244,35 +364,73 @@ func buildArgs(cfg config, meta *trackMetadata) ([]string, error) {
 		}
 	}
 
-	args = append(args, cfg.URL)
+	// Playlists and channels are expanded by ytcli itself, so each yt-dlp
+	// invocation downloads exactly one video.
+	args = append(args, "--no-playlist", cfg.URL)
 	return args, nil
 }
 
 func newFlagSet(cfg *config, stderr io.Writer) *flag.FlagSet {
-	fs := flag.NewFlagSet("ytcli", flag.ContinueOnError)
+	fs := flag.NewFlagSet("ytcli get", flag.ContinueOnError)
 	fs.SetOutput(stderr)
-	fs.StringVar(&cfg.Start, "start", "", "clip start timestamp (MM:SS or HH:MM:SS)")
-	fs.StringVar(&cfg.End, "end", "", "clip end timestamp (MM:SS or HH:MM:SS)")
-	fs.StringVar(&cfg.Mode, "mode", "full", "download mode: audio, video, or full")
-	fs.StringVar(&cfg.Output, "output", "", "destination file path or directory")
-	fs.StringVar(&cfg.Artist, "artist", "", "manual artist tag override for audio mode")
-	fs.StringVar(&cfg.Song, "song", "", "manual song title tag override for audio mode")
-	fs.BoolVar(&cfg.AppleMusic, "apple-music", false, "when mode=audio, import downloaded track into Apple Music library (macOS)")
+	registerDownloadFlags(fs, cfg)
+	fs.StringVar(&cfg.BatchFile, "batch-file", "", "read urls from PATH, one per line (use - for stdin)")
+	fs.StringVar(&cfg.Items, "items", "", "playlist/channel entries to download, e.g. 1-10,15 (1-based)")
+	fs.BoolVar(&cfg.Reverse, "reverse", false, "download selected playlist/channel entries in reverse order")
+	fs.BoolVar(&cfg.NoArchive, "no-archive", false, "neither consult nor update the download archive")
+	fs.BoolVar(&cfg.Force, "force", false, "download even if the download archive already has this video")
+	fs.IntVar(&cfg.Jobs, "jobs", 1, "number of downloads to run concurrently for batch, playlist, and channel runs")
+	fs.BoolVar(&cfg.JSON, "json", false, "print a JSON result object on stdout; human-readable output goes to stderr")
+	fs.BoolVar(&cfg.DryRun, "dry-run", false, "print the yt-dlp, ffmpeg and osascript commands a download would run, without running them")
 	fs.BoolVar(&cfg.ShowVersion, "version", false, "print version and build metadata, then exit")
 	fs.Usage = func() {
-		fmt.Fprintf(stderr, "Usage:\n  ytcli [--start MM:SS|HH:MM:SS] [--end MM:SS|HH:MM:SS] [--mode audio|video|full] [--output PATH] [--artist NAME] [--song TITLE] [--apple-music] [--version] <url>\n  ytcli version\n")
+		fmt.Fprintf(stderr, "Usage:\n  ytcli [get] [--start TIME] [--end TIME|--duration TIME] [--clip START-END[:label]]... [--mode audio|video|full] [--audio-format FORMAT] [--max-height N] [--fps N] [--vcodec h264|vp9|av1] [--container mp4|mkv|webm] [--subs LANGS [--auto-subs] [--embed-subs]] [--output PATH] [--artist NAME] [--song TITLE] [--album NAME] [--year YEAR] [--genre NAME] [--apple-music] [--cover FILE|--no-cover] [--square-cover] [--normalize [--target-lufs LUFS]] [--replaygain] [--trim-silence [--silence-threshold DB]] [--fade-in DURATION] [--fade-out DURATION] [--items RANGES] [--reverse] [--split-chapters] [--jobs N] [--no-archive] [--force] [--config PATH] [--profile NAME] [--json] [--dry-run] [--version] <url>\n  ytcli [get] [flags] --batch-file PATH|-\n\nDownload media from a url.\n\nFlags:\n")
 		fs.PrintDefaults()
+		fmt.Fprintf(stderr, "\nRun 'ytcli help' to list all commands.\n")
 	}
 	return fs
 }
 
+// registerDownloadFlags defines the flags that shape a download on fs. get and
+// info share them, so both see the same defaults and config file layering.
+func registerDownloadFlags(fs *flag.FlagSet, cfg *config) {
+	fs.StringVar(&cfg.Start, "start", "", "clip start timestamp (MM:SS, HH:MM:SS, seconds or 1h2m3s)")
+	fs.StringVar(&cfg.End, "end", "", "clip end timestamp; a leading - counts back from the end of the video (e.g. -0:30)")
+	fs.StringVar(&cfg.Duration, "duration", "", "clip length from --start, instead of --end")
+	fs.Var(clipListFlag{&cfg.Clips}, "clip", "clip range START-END[:label]; repeat for one output file per range")
+	fs.StringVar(&cfg.Mode, "mode", "full", "download mode: audio, video, or full")
+	fs.StringVar(&cfg.AudioFormat, "audio-format", "mp3", "audio mode output format: mp3, m4a, opus, flac, wav, or best (keep the original stream)")
+	fs.IntVar(&cfg.MaxHeight, "max-height", 0, "video and full modes: highest video resolution to download, e.g. 720")
+	fs.IntVar(&cfg.FPS, "fps", 0, "video and full modes: highest frame rate to download, e.g. 30")
+	fs.StringVar(&cfg.VCodec, "vcodec", "", "video and full modes: required video codec: h264, vp9, or av1")
+	fs.StringVar(&cfg.Container, "container", "mp4", "video and full modes: output container: mp4, mkv, or webm")
+	fs.StringVar(&cfg.Subs, "subs", "", "video and full modes: download subtitles for these languages, e.g. en,de (converted to srt)")
+	fs.BoolVar(&cfg.AutoSubs, "auto-subs", false, "with --subs, fall back to auto-generated subtitles")
+	fs.BoolVar(&cfg.EmbedSubs, "embed-subs", false, "with --subs, embed subtitles as soft tracks instead of writing .srt files")
+	fs.StringVar(&cfg.Output, "output", "", "destination file path or directory")
+	fs.StringVar(&cfg.Artist, "artist", "", "manual artist tag override for audio mode")
+	fs.StringVar(&cfg.Song, "song", "", "manual song title tag override for audio mode")
+	fs.StringVar(&cfg.Album, "album", "", "album tag override for audio mode")
+	fs.StringVar(&cfg.Year, "year", "", "release year or date (YYYY or YYYY-MM-DD) tag override for audio mode")
+	fs.StringVar(&cfg.Genre, "genre", "", "genre tag override for audio mode")
+	fs.BoolVar(&cfg.AppleMusic, "apple-music", false, "when mode=audio, import downloaded track into Apple Music library (macOS)")
+	fs.StringVar(&cfg.Cover, "cover", "", "embed this image as cover art instead of the video thumbnail (audio mode)")
+	fs.BoolVar(&cfg.NoCover, "no-cover", false, "do not embed cover art (audio mode)")
+	fs.BoolVar(&cfg.SquareCover, "square-cover", false, "crop the cover art to a centered square (audio mode)")
+	fs.BoolVar(&cfg.Normalize, "normalize", false, "normalize loudness to --target-lufs with a two-pass EBU R128 analysis (audio or full mode)")
+	fs.Float64Var(&cfg.TargetLUFS, "target-lufs", defaultTargetLUFS, "integrated loudness target for --normalize, in LUFS")
+	fs.BoolVar(&cfg.ReplayGain, "replaygain", false, "write ReplayGain track tags, plus album tags for playlists and chapter splits (audio mode)")
+	fs.BoolVar(&cfg.TrimSilence, "trim-silence", false, "cut silence from the start and end of the download (audio or full mode)")
+	fs.Float64Var(&cfg.SilenceThreshold, "silence-threshold", defaultSilenceThreshold, "level in dB below which --trim-silence treats audio as silent")
+	fs.StringVar(&cfg.FadeIn, "fade-in", "", "fade in over DURATION, e.g. 2s (audio or full mode)")
+	fs.StringVar(&cfg.FadeOut, "fade-out", "", "fade out over DURATION, e.g. 3.5s (audio or full mode)")
+	fs.BoolVar(&cfg.Split, "split-chapters", false, "write one tagged file per video chapter")
+	fs.StringVar(&cfg.ConfigPath, "config", "", "read flag defaults from this JSON file instead of the user config file")
+	fs.StringVar(&cfg.Profile, "profile", "", "apply the named profile from the config file")
+}
+
 func parseConfig(args []string, stderr io.Writer) (config, *flag.FlagSet, error) {
 	var cfg config
-	if len(args) == 1 && args[0] == "version" {
-		cfg.ShowVersion = true
-		return cfg, nil, nil
-	}
-
 	fs := newFlagSet(&cfg, stderr)
 	if err := fs.Parse(args); err != nil {
 		return cfg, fs, err
@@ -282,36 +440,142 @@ func parseConfig(args []string, stderr io.Writer) (config, *flag.FlagSet, error)
 		return cfg, fs, nil
 	}
 
-	if fs.NArg() != 1 {
-		return cfg, fs, fmt.Errorf("missing required url argument")
+	if err := applyUserConfig(fs, cfg.ConfigPath, cfg.Profile); err != nil {
+		return cfg, fs, err
 	}
-	cfg.URL = fs.Arg(0)
 
-	start, err := normalizeTimestamp(cfg.Start)
+	if cfg.BatchFile != "" {
+		if fs.NArg() != 0 {
+			return cfg, fs, fmt.Errorf("--batch-file cannot be combined with a url argument")
+		}
+	} else {
+		if fs.NArg() != 1 {
+			return cfg, fs, fmt.Errorf("missing required url argument")
+		}
+		cfg.URL = fs.Arg(0)
+	}
+
+	if err := resolveClipOptions(&cfg); err != nil {
+		return cfg, fs, err
+	}
+	if cfg.Jobs < 1 {
+		return cfg, fs, fmt.Errorf("--jobs must be at least 1")
+	}
+	ranges, err := parseItemRanges(cfg.Items)
 	if err != nil {
 		return cfg, fs, err
 	}
+	cfg.ItemRanges = ranges
+	if (len(cfg.ItemRanges) > 0 || cfg.Reverse) && cfg.BatchFile == "" && !isCollectionURL(cfg.URL) {
+		return cfg, fs, fmt.Errorf("--items and --reverse require a playlist or channel url (or --batch-file)")
+	}
+	if err := validateOptions(cfg); err != nil {
+		return cfg, fs, err
+	}
+
+	return cfg, fs, nil
+}
+
+// resolveClipOptions normalizes the clip timestamps and turns --duration and a
+// single --clip into a --start/--end range.
+func resolveClipOptions(cfg *config) error {
+	start, err := normalizeTimestamp(cfg.Start)
+	if err != nil {
+		return err
+	}
 	end, err := normalizeTimestamp(cfg.End)
 	if err != nil {
-		return cfg, fs, err
+		return err
+	}
+	if isEndOffset(start) {
+		return fmt.Errorf("--start must not be negative; only --end counts back from the end of the video")
+	}
+	if cfg.Duration != "" {
+		if end != "" {
+			return fmt.Errorf("--duration cannot be combined with --end")
+		}
+		duration, err := normalizeTimestamp(cfg.Duration)
+		if err != nil {
+			return err
+		}
+		if isEndOffset(duration) || timestampToSeconds(duration) <= 0 {
+			return fmt.Errorf("--duration must be greater than zero")
+		}
+		end = formatTimestamp(timestampToSeconds(start) + timestampToSeconds(duration))
 	}
 	cfg.Start = start
 	cfg.End = end
 
-	if cfg.Start != "" && cfg.End != "" && timestampToSeconds(cfg.End) <= timestampToSeconds(cfg.Start) {
-		return cfg, fs, fmt.Errorf("--end must be greater than --start")
+	if cfg.Start != "" && cfg.End != "" && !isEndOffset(cfg.End) && timestampToSeconds(cfg.End) <= timestampToSeconds(cfg.Start) {
+		return fmt.Errorf("--end must be greater than --start")
+	}
+	if len(cfg.Clips) > 0 && (cfg.Start != "" || cfg.End != "") {
+		return fmt.Errorf("--clip cannot be combined with --start, --end or --duration")
+	}
+	if len(cfg.Clips) == 1 {
+		// A single clip is the same download as --start/--end; it only gets a
+		// suffix when explicitly labelled.
+		cfg.Start = cfg.Clips[0].Start
+		cfg.End = cfg.Clips[0].End
+		cfg.ClipLabel = cfg.Clips[0].Label
+		cfg.Clips = nil
+	}
+	assignClipLabels(cfg.Clips)
+	if cfg.Split && (cfg.Start != "" || cfg.End != "" || len(cfg.Clips) > 0) {
+		return fmt.Errorf("--split-chapters cannot be combined with --start, --end, --duration or --clip")
+	}
+	if cfg.Split && strings.TrimSpace(cfg.Song) != "" {
+		return fmt.Errorf("--song cannot be combined with --split-chapters")
+	}
+	return nil
+}
+
+// validateOptions checks the download options against each other and the
+// mode. get and info share it, so info rejects what a download would.
+func validateOptions(cfg config) error {
+	if err := validateAudioFormat(cfg.AudioFormat); err != nil {
+		return err
+	}
+	if cfg.audioFormat() != "mp3" && cfg.Mode != "audio" {
+		return fmt.Errorf("--audio-format is only supported with --mode audio")
+	}
+	if err := validateVideoOptions(cfg); err != nil {
+		return err
+	}
+	if err := validateCoverOptions(cfg); err != nil {
+		return err
+	}
+	if err := validateSubtitleOptions(cfg); err != nil {
+		return err
+	}
+	if err := validateNormalizeOptions(cfg); err != nil {
+		return err
+	}
+	if err := validateReplayGainOptions(cfg); err != nil {
+		return err
+	}
+	if err := validateEffectOptions(cfg); err != nil {
+		return err
 	}
 	if cfg.AppleMusic && cfg.Mode != "audio" {
-		return cfg, fs, fmt.Errorf("--apple-music is only supported with --mode audio")
+		return fmt.Errorf("--apple-music is only supported with --mode audio")
+	}
+	if cfg.AppleMusic && !appleMusicFormats[cfg.audioFormat()] {
+		return fmt.Errorf("--apple-music requires --audio-format mp3, m4a or wav; Music.app cannot import %s", cfg.audioFormat())
 	}
 	if (strings.TrimSpace(cfg.Artist) != "" || strings.TrimSpace(cfg.Song) != "") && cfg.Mode != "audio" {
-		return cfg, fs, fmt.Errorf("--artist and --song are only supported with --mode audio")
+		return fmt.Errorf("--artist and --song are only supported with --mode audio")
 	}
 	if cfg.Song != "" && cleanTitle(cfg.Song) == "" {
-		return cfg, fs, fmt.Errorf("--song must not be empty")
+		return fmt.Errorf("--song must not be empty")
 	}
-
-	return cfg, fs, nil
+	if (cfg.Album != "" || cfg.Year != "" || cfg.Genre != "") && cfg.Mode != "audio" {
+		return fmt.Errorf("--album, --year and --genre are only supported with --mode audio")
+	}
+	if err := validateYear(cfg.Year); err != nil {
+		return err
+	}
+	return nil
 }
 
 func applyManualMetadata(base *trackMetadata, artistOverride, songOverride string) (*trackMetadata, bool) {
@@ -337,7 +601,26 @@ func applyManualMetadata(base *trackMetadata, artistOverride, songOverride strin
 	return &combined, true
 }
 
-func importIntoAppleMusic(path string) error {
+// appleMusicMu serializes imports; Music.app handles concurrent AppleScript
+// "add" commands poorly.
+var appleMusicMu sync.Mutex
+
+const appleMusicImportScript = `
+on run argv
+	set targetPath to POSIX file (item 1 of argv)
+	tell application "Music"
+		add targetPath
+	end tell
+end run
+`
+
+// appleMusicImportArgs returns the osascript arguments that add path to the
+// Music library.
+func appleMusicImportArgs(path string) []string {
+	return []string{"-e", appleMusicImportScript, path}
+}
+
+func importIntoAppleMusic(ctx context.Context, path string) error {
 	if runtime.GOOS != "darwin" {
 		return fmt.Errorf("--apple-music is only supported on macOS")
 	}
@@ -350,15 +633,10 @@ func importIntoAppleMusic(path string) error {
 		return fmt.Errorf("downloaded file not found for Apple Music import: %w", err)
 	}
 
-	script := `
-on run argv
-	set targetPath to POSIX file (item 1 of argv)
-	tell application "Music"
-		add targetPath
-	end tell
-end run
-`
-	cmd := exec.Command("osascript", "-e", script, absPath)
+	appleMusicMu.Lock()
+	defer appleMusicMu.Unlock()
+
+	cmd := runner.Command(ctx, "osascript", appleMusicImportArgs(absPath)...)
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		message := strings.TrimSpace(string(out))
@@ -372,18 +650,26 @@ end run
 }
 
 func parseFinalPathLine(line string) (string, bool) {
-	if !strings.HasPrefix(line, finalPathPrefix) {
+	return parsePrintedPath(line, finalPathPrefix)
+}
+
+// parsePrintedPath returns the path in a line ytcli had yt-dlp print with
+// prefix. yt-dlp prints NA for a path it does not have.
+func parsePrintedPath(line, prefix string) (string, bool) {
+	if !strings.HasPrefix(line, prefix) {
 		return "", false
 	}
 
-	path := strings.TrimSpace(strings.TrimPrefix(line, finalPathPrefix))
-	if path == "" {
+	path := strings.TrimSpace(strings.TrimPrefix(line, prefix))
+	if path == "" || path == "NA" {
 		return "", false
 	}
 	return path, true
 }
 
-func writeAudioMetadata(path string, meta trackMetadata) error {
+// writeAudioMetadata rewrites the tags of path and, when cover is non-nil,
+// embeds it as the front cover in the same ffmpeg pass.
+func writeAudioMetadata(ctx context.Context, path string, meta trackMetadata, cover *coverArt) error {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
 		return fmt.Errorf("failed to resolve downloaded file path: %w", err)
@@ -392,24 +678,20 @@ func writeAudioMetadata(path string, meta trackMetadata) error {
 		return fmt.Errorf("downloaded file not found for metadata tagging: %w", err)
 	}
 
+	// The temp name is unique per call so concurrent jobs that resolve to the
+	// same output name never share a tagging file. ffmpeg picks the muxer from
+	// the extension, so it stays last.
 	ext := filepath.Ext(absPath)
 	base := strings.TrimSuffix(filepath.Base(absPath), ext)
-	tmpPath := filepath.Join(filepath.Dir(absPath), base+".ytcli-tagging"+ext)
+	tmpFile, err := os.CreateTemp(filepath.Dir(absPath), base+".ytcli-tagging-*"+ext)
+	if err != nil {
+		return fmt.Errorf("failed to create temporary tagging file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	tmpFile.Close()
 	defer os.Remove(tmpPath)
 
-	cmd := exec.Command(
-		"ffmpeg",
-		"-hide_banner",
-		"-loglevel", "error",
-		"-nostdin",
-		"-y",
-		"-i", absPath,
-		"-map", "0",
-		"-c", "copy",
-		"-metadata", "artist="+meta.Artist,
-		"-metadata", "title="+meta.Title,
-		tmpPath,
-	)
+	cmd := runner.Command(ctx, "ffmpeg", tagArgs(absPath, tmpPath, meta, cover)...)
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		message := strings.TrimSpace(string(out))
@@ -425,6 +707,26 @@ func writeAudioMetadata(path string, meta trackMetadata) error {
 	return nil
 }
 
+// tagArgs returns the ffmpeg arguments that copy path to out with the tags
+// of meta and, when non-nil, the cover.
+func tagArgs(path, out string, meta trackMetadata, cover *coverArt) []string {
+	args := []string{
+		"-hide_banner",
+		"-loglevel", "error",
+		"-nostdin",
+		"-y",
+		"-i", path,
+	}
+	if cover != nil {
+		args = append(args, "-i", cover.Path)
+		args = append(args, coverArgs(*cover)...)
+	} else {
+		args = append(args, "-map", "0", "-c", "copy")
+	}
+	args = append(args, metadataArgs(meta, filepath.Ext(out))...)
+	return append(args, out)
+}
+
 func inferTrackMetadataFromPath(path string) (trackMetadata, bool) {
 	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
 	base = strings.TrimSpace(base)
@@ -449,7 +751,7 @@ func inferTrackMetadataFromPath(path string) (trackMetadata, bool) {
 }
 
 func resolveYtDlpBinary() (string, error) {
-	if p, err := exec.LookPath("yt-dlp"); err == nil {
+	if p, err := runner.LookPath("yt-dlp"); err == nil {
 		return p, nil
 	}
 
@@ -466,170 +768,273 @@ func resolveYtDlpBinary() (string, error) {
 	return "", fmt.Errorf("yt-dlp is not installed or not available in PATH (and .venv/bin/yt-dlp was not found)")
 }
 
-func fetchTrackMetadata(ytDlpBinary, url string) (*trackMetadata, error) {
-	cmd := exec.Command(
-		ytDlpBinary,
-		"--skip-download",
-		"--no-warnings",
-		"--print", "%(artist,uploader)s",
-		"--print", "%(track,title)s",
-		url,
-	)
-	out, err := cmd.Output()
+func fetchTrackMetadata(ctx context.Context, ytDlpBinary, url string) (*trackMetadata, error) {
+	info, err := fetchVideoInfo(ctx, ytDlpBinary, url)
 	if err != nil {
 		return nil, err
 	}
+	return info.tagMetadata()
+}
 
-	lines := []string{}
-	for _, line := range strings.Split(string(out), "\n") {
-		trimmed := strings.TrimSpace(line)
-		if trimmed != "" {
-			lines = append(lines, trimmed)
-		}
+// run downloads a single url. The returned result is never nil and
+// describes the outcome, including when err is non-nil.
+func run(ctx context.Context, cfg config, stdout, stderr io.Writer) (result *downloadResult, err error) {
+	result = newDownloadResult(cfg)
+	defer func() {
+		err = interruptedError(ctx, err)
+		result.finish(err)
+	}()
+
+	ytDlpBinary, err := resolveYtDlpBinary()
+	if err != nil {
+		return result, withKind(errKindDependency, err)
 	}
-	if len(lines) < 2 {
-		return nil, fmt.Errorf("failed to fetch artist/title metadata")
+
+	// Clip ranges are checked against the real duration, which also turns an
+	// end offset into an absolute timestamp before anything else uses it.
+	if cfg.Start != "" || cfg.End != "" {
+		duration, durationErr := fetchDuration(ctx, ytDlpBinary, cfg.URL)
+		switch {
+		case durationErr == nil:
+			cfg.Start, cfg.End, err = resolveClipRange(cfg.Start, cfg.End, duration)
+			if err != nil {
+				return result, withKind(errKindUsage, err)
+			}
+			result.Clip.Start = cfg.Start
+			result.Clip.End = cfg.End
+		case isEndOffset(cfg.End):
+			return result, withKind(errKindDownload, fmt.Errorf("cannot resolve end %s without the video duration: %w", cfg.End, durationErr))
+		default:
+			result.warn(stderr, "could not check the clip range against the video duration (%v)", durationErr)
+		}
 	}
 
-	meta := trackMetadata{
-		Artist: cleanArtist(lines[0]),
-		Title:  cleanTitle(lines[1]),
+	var archive *downloadArchive
+	var archiveKey string
+	if !cfg.NoArchive {
+		archive, err = openArchive()
+		if err == nil {
+			archiveKey, err = resolveArchiveKey(ctx, ytDlpBinary, cfg.URL)
+		}
+		if err != nil {
+			result.warn(stderr, "download archive unavailable, continuing without it (%v)", err)
+			archive = nil
+		} else if entry, ok := archive.lookup(archiveKey, archiveVariant(cfg)); ok && !cfg.Force {
+			result.Path = entry.Path
+			result.Artist = entry.Artist
+			result.Title = entry.Title
+			if entry.Path != "" {
+				return result, fmt.Errorf("%w: %s", errAlreadyDownloaded, entry.Path)
+			}
+			return result, errAlreadyDownloaded
+		}
 	}
-	if meta.Title == "" {
-		return nil, fmt.Errorf("missing track title metadata")
+
+	// A chapter split needs the chapter list up front; videos without
+	// chapters are downloaded as one file.
+	var chapterInfo *videoInfo
+	if cfg.Split {
+		info, infoErr := fetchVideoInfo(ctx, ytDlpBinary, cfg.URL)
+		switch {
+		case infoErr != nil:
+			result.warn(stderr, "failed to fetch chapters, downloading as one file (%v)", infoErr)
+		case len(info.Chapters) == 0:
+			result.warn(stderr, "video has no chapters, downloading as one file")
+		default:
+			chapterInfo = info
+			fmt.Fprintf(stdout, "Found %d chapters: %s\n", len(info.Chapters), cleanTitle(info.Title))
+		}
 	}
-	return &meta, nil
-}
 
-func run(cfg config, stdout, stderr io.Writer) error {
-	ytDlpBinary, err := resolveYtDlpBinary()
-	if err != nil {
-		return err
+	// yt-dlp only reports "requested format is not available", so video
+	// constraints are checked up front to name what the video does offer.
+	if cfg.Mode != "audio" && cfg.hasVideoConstraints() {
+		info := chapterInfo
+		var infoErr error
+		if info == nil {
+			info, infoErr = fetchVideoInfo(ctx, ytDlpBinary, cfg.URL)
+		}
+		if infoErr != nil {
+			result.warn(stderr, "could not check available formats (%v)", infoErr)
+		} else if err := checkVideoFormats(info.Formats, cfg); err != nil {
+			return result, withKind(errKindConfig, err)
+		}
 	}
 
 	var meta *trackMetadata
 	if cfg.Mode == "audio" {
-		fetchedMeta, fetchErr := fetchTrackMetadata(ytDlpBinary, cfg.URL)
+		var fetchedMeta *trackMetadata
+		var fetchErr error
+		if chapterInfo != nil {
+			fetchedMeta, fetchErr = chapterInfo.tagMetadata()
+		} else {
+			fetchedMeta, fetchErr = fetchTrackMetadata(ctx, ytDlpBinary, cfg.URL)
+		}
 		if fetchErr == nil {
 			meta = fetchedMeta
 			fmt.Fprintf(stdout, "Parsed audio metadata: %s - %s\n", meta.Artist, meta.Title)
 		} else {
-			fmt.Fprintf(stderr, "Warning: metadata parsing failed, using yt-dlp artist/title fallback template (%v)\n", fetchErr)
+			result.warn(stderr, "metadata parsing failed, using yt-dlp artist/title fallback template (%v)", fetchErr)
 		}
 
 		if updatedMeta, applied := applyManualMetadata(meta, cfg.Artist, cfg.Song); applied {
 			meta = updatedMeta
 			fmt.Fprintf(stdout, "Using manual metadata override: %s - %s\n", meta.Artist, meta.Title)
 		}
+
+		if meta != nil && cfg.ClipLabel != "" {
+			clipMeta := *meta
+			clipMeta.Title += clipSuffix(cfg.ClipLabel)
+			meta = &clipMeta
+		}
 	}
 
 	args, err := buildArgs(cfg, meta)
 	if err != nil {
-		return err
+		return result, withKind(errKindConfig, err)
 	}
 
 	var downloadedPath string
-	captureFinalPath := cfg.Mode == "audio" || cfg.AppleMusic
+	sidecarSubs := cfg.Subs != "" && !cfg.EmbedSubs
+	captureFinalPath := cfg.Mode == "audio" || cfg.Normalize || cfg.hasEffects() || cfg.AppleMusic || archive != nil || cfg.JSON || chapterInfo != nil || sidecarSubs
 	if captureFinalPath {
 		args = append(args, "--print", "after_move:"+finalPathPrefix+"%(filepath)s")
 	}
 
-	cmd := exec.Command(ytDlpBinary, args...)
+	if cfg.DryRun {
+		return result, dryRun(ctx, result, cfg, ytDlpBinary, args, meta, chapterInfo, stdout, stderr)
+	}
+
+	cmd := runner.Command(ctx, ytDlpBinary, args...)
 	cmd.Stderr = stderr
-	if captureFinalPath {
-		cmdStdout, err := cmd.StdoutPipe()
-		if err != nil {
-			return fmt.Errorf("failed to capture yt-dlp output: %w", err)
+	cmdStdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return result, withKind(errKindInternal, fmt.Errorf("failed to capture yt-dlp output: %w", err))
+	}
+	if err := cmd.Start(); err != nil {
+		return result, withKind(errKindDependency, fmt.Errorf("failed to start download: %w", err))
+	}
+
+	// yt-dlp keeps partial files around to resume later; when the download
+	// fails or is interrupted they are removed along with the streams and
+	// thumbnail it had written so far.
+	artifacts := newDownloadArtifacts()
+	progress := newProgressRenderer(stdout)
+	scanner := bufio.NewScanner(cmdStdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		artifacts.track(line)
+		if parsedPath, ok := parseFinalPathLine(line); ok {
+			downloadedPath = parsedPath
+			continue
+		}
+		if ev, ok := parseProgressLine(line); ok {
+			progress.Update(ev)
+			continue
 		}
-		if err := cmd.Start(); err != nil {
-			return fmt.Errorf("failed to start download: %w", err)
+		if strings.HasPrefix(line, thumbnailPrefix) {
+			continue
 		}
+		progress.Flush()
+		fmt.Fprintln(stdout, line)
+	}
+	progress.Flush()
+	readErr := scanner.Err()
+	if readErr != nil {
+		// yt-dlp blocks once its output is no longer read, so it has to be
+		// stopped, and what it already wrote drained, before waiting for it.
+		cmd.Process.Kill()
+		io.Copy(io.Discard, cmdStdout)
+	}
+	waitErr := cmd.Wait()
+	// Wait reports a cancelled context even when yt-dlp exited 0 because it
+	// finished before the interrupt reached it.
+	finished := waitErr == nil || (cmd.ProcessState != nil && cmd.ProcessState.Success())
+	switch {
+	case readErr != nil:
+		artifacts.remove()
+		return result, withKind(errKindDownload, fmt.Errorf("failed to read yt-dlp output: %w", readErr))
+	case !finished:
+		artifacts.remove()
+		return result, withKind(errKindDownload, fmt.Errorf("download failed: %w", waitErr))
+	case ctx.Err() != nil:
+		// yt-dlp finished before the interrupt reached it. The streams it
+		// downloaded are merged and gone, or are the download itself, so
+		// only the thumbnail is left over.
+		artifacts.removeThumbnail()
+		return result, withKind(errKindInterrupted, errInterrupted)
+	}
+
+	result.Path = downloadedPath
+
+	job := &postJob{
+		Cfg:    cfg,
+		Path:   downloadedPath,
+		Files:  []string{downloadedPath},
+		Meta:   meta,
+		Info:   chapterInfo,
+		Result: result,
+		Stdout: stdout,
+		Stderr: stderr,
+	}
+	err = newPostPipeline().run(ctx, job)
+	result.setMetadata(job.Meta)
+	if err != nil {
+		return result, err
+	}
+	downloadedPath, meta = job.Path, job.Meta
 
-		scanner := bufio.NewScanner(cmdStdout)
-		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
-		for scanner.Scan() {
-			line := scanner.Text()
-			if parsedPath, ok := parseFinalPathLine(line); ok {
-				downloadedPath = parsedPath
-				continue
-			}
-			fmt.Fprintln(stdout, line)
+	if archive != nil {
+		entry := archiveEntry{
+			Key:          archiveKey,
+			Variant:      archiveVariant(cfg),
+			URL:          cfg.URL,
+			DownloadedAt: time.Now().UTC(),
 		}
-		if err := scanner.Err(); err != nil {
-			return fmt.Errorf("failed to read yt-dlp output: %w", err)
+		if downloadedPath != "" {
+			if absPath, err := filepath.Abs(downloadedPath); err == nil {
+				entry.Path = absPath
+			}
 		}
-		if err := cmd.Wait(); err != nil {
-			return fmt.Errorf("download failed: %w", err)
+		if meta != nil {
+			entry.Artist = meta.Artist
+			entry.Title = meta.Title
 		}
-	} else {
-		cmd.Stdout = stdout
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("download failed: %w", err)
+		if err := archive.record(entry); err != nil {
+			result.warn(stderr, "failed to update download archive (%v)", err)
 		}
 	}
 
-	if cfg.Mode == "audio" {
-		if strings.TrimSpace(downloadedPath) == "" {
-			fmt.Fprintln(stderr, "Warning: download completed but output path was unavailable, skipping metadata tagging")
-		} else {
-			needsInference := meta == nil ||
-				strings.TrimSpace(meta.Title) == "" ||
-				strings.TrimSpace(meta.Artist) == "" ||
-				(meta.Artist == "Unknown Artist" && strings.TrimSpace(cfg.Artist) == "")
-			if needsInference {
-				inferredMeta, ok := inferTrackMetadataFromPath(downloadedPath)
-				if !ok {
-					fmt.Fprintln(stderr, "Warning: metadata unavailable and could not infer tags from file name")
-				} else if meta == nil {
-					meta = &inferredMeta
-					fmt.Fprintf(stderr, "Warning: metadata fetch failed, inferred tags from filename: %s - %s\n", meta.Artist, meta.Title)
-				} else {
-					if strings.TrimSpace(meta.Title) == "" {
-						meta.Title = inferredMeta.Title
-					}
-					if strings.TrimSpace(meta.Artist) == "" || (meta.Artist == "Unknown Artist" && strings.TrimSpace(cfg.Artist) == "") {
-						meta.Artist = inferredMeta.Artist
-					}
-				}
-			}
+	fmt.Fprintln(stdout, "Download completed successfully.")
+	return result, nil
+}
 
-			if meta != nil && strings.TrimSpace(meta.Title) != "" {
-				if err := writeAudioMetadata(downloadedPath, *meta); err != nil {
-					fmt.Fprintf(stderr, "Warning: failed to write audio metadata tags (%v)\n", err)
-				} else {
-					fmt.Fprintf(stdout, "Tagged audio metadata: %s - %s\n", meta.Artist, meta.Title)
-				}
-			} else if meta != nil {
-				fmt.Fprintln(stderr, "Warning: metadata title is empty, skipping audio metadata tagging")
-			}
-		}
-	}
+func Main(args []string, stdout, stderr io.Writer) int {
+	ctx, stop := signalContext()
+	defer stop()
 
-	if cfg.AppleMusic {
-		if strings.TrimSpace(downloadedPath) == "" {
-			return fmt.Errorf("download completed but could not determine output path for Apple Music import")
+	if len(args) > 0 {
+		if args[0] == "help" {
+			writeCommandList(stdout)
+			return 0
 		}
-		if err := importIntoAppleMusic(downloadedPath); err != nil {
-			return err
+		if cmd, ok := lookupCommand(args[0]); ok {
+			return cmd.Run(ctx, args[1:], stdout, stderr)
 		}
-		fmt.Fprintf(stdout, "Imported into Apple Music: %s\n", downloadedPath)
 	}
 
-	fmt.Fprintln(stdout, "Download completed successfully.")
-	return nil
+	// A bare `ytcli [flags] <url>` is an alias for `ytcli get`.
+	return runGetCommand(ctx, args, stdout, stderr)
 }
 
-func Main(args []string, stdout, stderr io.Writer) int {
+func runGetCommand(ctx context.Context, args []string, stdout, stderr io.Writer) int {
 	cfg, fs, err := parseConfig(args, stderr)
 	if err != nil {
-		if errors.Is(err, flag.ErrHelp) {
-			return 0
-		}
-		fmt.Fprintf(stderr, "Error: %v\n", err)
-		if fs != nil {
-			fs.Usage()
+		if cfg.JSON && !errors.Is(err, flag.ErrHelp) {
+			writeJSON(stdout, failedResult(cfg, withKind(errKindUsage, err)))
 		}
-		return 2
+		return usageError(fs, stderr, err)
 	}
 
 	if cfg.ShowVersion {
@@ -637,8 +1042,64 @@ func Main(args []string, stdout, stderr io.Writer) int {
 		return 0
 	}
 
-	if err := run(cfg, stdout, stderr); err != nil {
+	// With --json, stdout carries only the result object and every
+	// human-oriented message, including yt-dlp's own output, goes to stderr.
+	humanOut := stdout
+	if cfg.JSON {
+		humanOut = stderr
+	}
+	fail := func(kind errorKind, err error) int {
 		fmt.Fprintf(stderr, "Error: %v\n", err)
+		if cfg.JSON {
+			writeJSON(stdout, failedResult(cfg, withKind(kind, err)))
+		}
+		return 1
+	}
+
+	if cfg.BatchFile == "" && !isCollectionURL(cfg.URL) && len(cfg.Clips) == 0 {
+		result, err := run(ctx, cfg, humanOut, stderr)
+		if cfg.JSON {
+			writeJSON(stdout, result)
+		}
+		if err != nil {
+			if errors.Is(err, errAlreadyDownloaded) {
+				fmt.Fprintf(humanOut, "Skipping %s: %v (use --force to download again)\n", cfg.URL, err)
+				return 0
+			}
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return failureStatus(ctx)
+		}
+		return 0
+	}
+
+	items := []batchItem{{URL: cfg.URL}}
+	if cfg.BatchFile != "" {
+		items, err = readBatchFile(cfg.BatchFile, os.Stdin)
+		if err != nil {
+			return fail(errKindUsage, err)
+		}
+	}
+
+	ytDlpBinary, err := resolveYtDlpBinary()
+	if err != nil {
+		return fail(errKindDependency, err)
+	}
+	items = expandBatchItems(ctx, ytDlpBinary, items, cfg.ItemRanges, cfg.Reverse)
+	items = expandClipItems(items, cfg.Clips)
+
+	results := runBatch(ctx, cfg, items, humanOut, stderr)
+	if cfg.ReplayGain && !cfg.Split && !cfg.DryRun && ctx.Err() == nil {
+		// Chapter splits already carry album gain per video.
+		applyAlbumReplayGain(ctx, results, humanOut, stderr)
+	}
+	writeBatchSummary(humanOut, results)
+	if cfg.JSON {
+		writeJSON(stdout, newBatchReport(results))
+	}
+	if ctx.Err() != nil {
+		return exitInterrupted
+	}
+	if batchHasFailures(results) {
 		return 1
 	}
 	return 0
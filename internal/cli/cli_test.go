@@ -16,7 +16,17 @@ func TestNormalizeTimestamp(t *testing.T) {
 	}{
 		{name: "mmss", input: "3:05", want: "00:03:05"},
 		{name: "hhmmss", input: "1:02:03", want: "01:02:03"},
-		{name: "invalid", input: "99", wantErr: true},
+		{name: "plain seconds", input: "99", want: "00:01:39"},
+		{name: "fractional", input: "1:02.5", want: "00:01:02.5"},
+		{name: "fractional seconds", input: "95.25", want: "00:01:35.25"},
+		{name: "units", input: "1h2m3s", want: "01:02:03"},
+		{name: "minutes only", input: "90m", want: "01:30:00"},
+		{name: "end offset", input: "-0:30", want: "-00:00:30"},
+		{name: "zero offset", input: "-0", wantErr: true},
+		{name: "invalid", input: "1:2:3:4", wantErr: true},
+		{name: "invalid units", input: "3s2m", wantErr: true},
+		{name: "invalid minutes", input: "75:00", wantErr: true},
+		{name: "empty sign", input: "-", wantErr: true},
 	}
 
 	for _, tc := range tests {
@@ -201,6 +211,16 @@ func TestApplyManualMetadata(t *testing.T) {
 	}
 }
 
+// expectParseError fails the test unless parseConfig rejects args with an
+// error containing want.
+func expectParseError(t *testing.T, want string, args ...string) {
+	t.Helper()
+	_, _, err := parseConfig(args, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("%v: expected error containing %q, got %v", args, want, err)
+	}
+}
+
 func TestParseConfigRejectsMetadataOverridesOutsideAudioMode(t *testing.T) {
 	_, _, err := parseConfig(
 		[]string{"--mode", "full", "--artist", "Daft Punk", "https://youtu.be/example"},
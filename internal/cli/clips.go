@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// reClipEnd splits "END[:label]". The end timestamp is matched greedily, so
+// "1:02:03" is a timestamp while "2:30:Intro" is "2:30" labelled "Intro".
+// Unit forms ("1h2m") and end offsets ("-0:30") are recognized as well.
+var reClipEnd = regexp.MustCompile(`^(-?(?:(?:\d+(?:\.\d+)?[hms])+|\d+(?::\d+){0,2}(?:\.\d+)?))?(?::(.*))?$`)
+
+type clipRange struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+	Label string `json:"label,omitempty"`
+}
+
+// parseClipSpec parses a --clip value of the form START-END[:label]. Either
+// timestamp may be omitted to clip from the beginning or to the end, and END
+// may count back from the end of the video ("1:00--0:30").
+func parseClipSpec(spec string) (clipRange, error) {
+	spec = strings.TrimSpace(spec)
+	startText, rest, ok := strings.Cut(spec, "-")
+	if !ok {
+		return clipRange{}, fmt.Errorf("invalid clip %q; use START-END[:label]", spec)
+	}
+
+	m := reClipEnd.FindStringSubmatch(strings.TrimSpace(rest))
+	if m == nil {
+		return clipRange{}, fmt.Errorf("invalid clip %q; use START-END[:label]", spec)
+	}
+
+	start, err := normalizeTimestamp(startText)
+	if err != nil {
+		return clipRange{}, err
+	}
+	end, err := normalizeTimestamp(m[1])
+	if err != nil {
+		return clipRange{}, err
+	}
+	if start == "" && end == "" {
+		return clipRange{}, fmt.Errorf("invalid clip %q; START or END is required", spec)
+	}
+	if start != "" && end != "" && !isEndOffset(end) && timestampToSeconds(end) <= timestampToSeconds(start) {
+		return clipRange{}, fmt.Errorf("invalid clip %q; end must be greater than start", spec)
+	}
+
+	return clipRange{Start: start, End: end, Label: strings.TrimSpace(m[2])}, nil
+}
+
+// resolveClipRange checks start and end against the video duration and turns
+// an end offset such as "-00:00:30" into an absolute timestamp.
+func resolveClipRange(start, end string, duration float64) (string, string, error) {
+	length := formatTimestamp(duration)
+	if start != "" && timestampToSeconds(start) >= duration {
+		return "", "", fmt.Errorf("start %s is beyond the end of the video (%s)", start, length)
+	}
+	if isEndOffset(end) {
+		resolved := duration + timestampToSeconds(end)
+		if resolved <= timestampToSeconds(start) {
+			return "", "", fmt.Errorf("end %s resolves to %s for a %s video, which is not after start %s", end, formatTimestamp(math.Max(resolved, 0)), length, formatTimestamp(timestampToSeconds(start)))
+		}
+		end = formatTimestamp(resolved)
+	} else if end != "" && timestampToSeconds(end) > duration {
+		return "", "", fmt.Errorf("end %s is beyond the end of the video (%s)", end, length)
+	}
+	return start, end, nil
+}
+
+// fetchDuration asks yt-dlp for the length of a video in seconds.
+func fetchDuration(ctx context.Context, ytDlpBinary, url string) (float64, error) {
+	cmd := runner.Command(
+		ctx,
+		ytDlpBinary,
+		"--skip-download",
+		"--no-warnings",
+		"--no-playlist",
+		"--print", "%(duration)s",
+		url,
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch video duration: %w", err)
+	}
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+	if err != nil || seconds <= 0 {
+		return 0, fmt.Errorf("video duration is unavailable")
+	}
+	return seconds, nil
+}
+
+// clipListFlag collects repeated --clip flags.
+type clipListFlag struct {
+	clips *[]clipRange
+}
+
+func (f clipListFlag) String() string {
+	if f.clips == nil {
+		return ""
+	}
+	parts := []string{}
+	for _, c := range *f.clips {
+		part := c.Start + "-" + c.End
+		if c.Label != "" {
+			part += ":" + c.Label
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (f clipListFlag) Set(value string) error {
+	clip, err := parseClipSpec(value)
+	if err != nil {
+		return err
+	}
+	*f.clips = append(*f.clips, clip)
+	return nil
+}
+
+// assignClipLabels gives unlabelled clips a "Part N" label.
+func assignClipLabels(clips []clipRange) {
+	for i := range clips {
+		if clips[i].Label == "" {
+			clips[i].Label = fmt.Sprintf("Part %d", i+1)
+		}
+	}
+}
+
+func clipSuffix(label string) string {
+	if strings.TrimSpace(label) == "" {
+		return ""
+	}
+	return " (" + label + ")"
+}
+
+// withClipSuffix inserts the clip suffix before the file extension.
+func withClipSuffix(path, label string) string {
+	if label == "" {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + " (" + sanitizeFilenamePart(label) + ")" + ext
+}
+
+// expandClipItems turns every item into one item per clip range.
+func expandClipItems(items []batchItem, clips []clipRange) []batchItem {
+	if len(clips) == 0 {
+		return items
+	}
+
+	expanded := make([]batchItem, 0, len(items)*len(clips))
+	for _, item := range items {
+		if item.Err != nil || item.SkipReason != "" {
+			expanded = append(expanded, item)
+			continue
+		}
+		for i := range clips {
+			clipItem := item
+			clipItem.Clip = &clips[i]
+			clipItem.Part = i + 1
+			expanded = append(expanded, clipItem)
+		}
+	}
+	return expanded
+}
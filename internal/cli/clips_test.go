@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseClipSpec(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    clipRange
+		wantErr bool
+	}{
+		{input: "0:30-1:00", want: clipRange{Start: "00:00:30", End: "00:01:00"}},
+		{input: "1:00:00-1:02:03", want: clipRange{Start: "01:00:00", End: "01:02:03"}},
+		{input: "0:30-2:30:Intro", want: clipRange{Start: "00:00:30", End: "00:02:30", Label: "Intro"}},
+		{input: "1:00:00-1:02:03:Encore Set", want: clipRange{Start: "01:00:00", End: "01:02:03", Label: "Encore Set"}},
+		{input: "-1:00", want: clipRange{End: "00:01:00"}},
+		{input: "59:00-", want: clipRange{Start: "00:59:00"}},
+		{input: "90-2m30.5s:Drop", want: clipRange{Start: "00:01:30", End: "00:02:30.5", Label: "Drop"}},
+		{input: "1:00--0:30", want: clipRange{Start: "00:01:00", End: "-00:00:30"}},
+		{input: "1h-1h2m:Encore", want: clipRange{Start: "01:00:00", End: "01:02:00", Label: "Encore"}},
+		{input: "1:00-0:30", wantErr: true},
+		{input: "1:00", wantErr: true},
+		{input: "-", wantErr: true},
+		{input: "abc-1:00", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		got, err := parseClipSpec(tc.input)
+		if tc.wantErr {
+			if err == nil {
+				t.Fatalf("%q: expected error, got %+v", tc.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tc.input, err)
+		}
+		if got != tc.want {
+			t.Fatalf("%q: got %+v, want %+v", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestParseConfigClips(t *testing.T) {
+	cfg, _, err := parseConfig([]string{"--clip", "0:10-0:20", "--clip", "1:00-1:30:Chorus", "https://youtu.be/example"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Clips) != 2 {
+		t.Fatalf("got %d clips, want 2", len(cfg.Clips))
+	}
+	if cfg.Clips[0].Label != "Part 1" || cfg.Clips[1].Label != "Chorus" {
+		t.Fatalf("unexpected labels: %+v", cfg.Clips)
+	}
+
+	cfg, _, err = parseConfig([]string{"--clip", "0:10-0:20", "https://youtu.be/example"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Clips) != 0 || cfg.Start != "00:00:10" || cfg.End != "00:00:20" || cfg.ClipLabel != "" {
+		t.Fatalf("single clip should behave like --start/--end: %+v", cfg)
+	}
+
+	_, _, err = parseConfig([]string{"--clip", "0:10-0:20", "--start", "0:05", "https://youtu.be/example"}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "cannot be combined") {
+		t.Fatalf("expected combination error, got %v", err)
+	}
+}
+
+func TestResolveClipRange(t *testing.T) {
+	tests := []struct {
+		start, end string
+		duration   float64
+		wantStart  string
+		wantEnd    string
+		wantErr    string
+	}{
+		{start: "00:01:00", end: "-00:00:30", duration: 300, wantStart: "00:01:00", wantEnd: "00:04:30"},
+		{end: "-00:00:00.5", duration: 60.25, wantEnd: "00:00:59.75"},
+		{start: "00:01:00", end: "00:05:00", duration: 300, wantStart: "00:01:00", wantEnd: "00:05:00"},
+		{start: "00:05:00", duration: 300, wantErr: "beyond the end of the video (00:05:00)"},
+		{end: "00:05:01", duration: 300, wantErr: "beyond the end of the video"},
+		{start: "00:04:45", end: "-00:00:30", duration: 300, wantErr: "resolves to 00:04:30 for a 00:05:00 video"},
+		{end: "-00:10:00", duration: 300, wantErr: "resolves to 00:00:00"},
+	}
+
+	for _, tc := range tests {
+		start, end, err := resolveClipRange(tc.start, tc.end, tc.duration)
+		if tc.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("%s-%s: expected error containing %q, got %v", tc.start, tc.end, tc.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s-%s: unexpected error: %v", tc.start, tc.end, err)
+		}
+		if start != tc.wantStart || end != tc.wantEnd {
+			t.Fatalf("%s-%s: got %q-%q, want %q-%q", tc.start, tc.end, start, end, tc.wantStart, tc.wantEnd)
+		}
+	}
+}
+
+func TestParseConfigDuration(t *testing.T) {
+	cfg, _, err := parseConfig([]string{"--start", "1:30", "--duration", "45.5", "https://youtu.be/example"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Start != "00:01:30" || cfg.End != "00:02:15.5" {
+		t.Fatalf("got %q-%q", cfg.Start, cfg.End)
+	}
+
+	cfg, _, err = parseConfig([]string{"--start", "1m", "--end", "-0:30", "https://youtu.be/example"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.End != "-00:00:30" {
+		t.Fatalf("end offset should be kept until the duration is known, got %q", cfg.End)
+	}
+
+	expectParseError(t, "cannot be combined with --end", "--duration", "10", "--end", "1:00", "https://youtu.be/example")
+	expectParseError(t, "greater than zero", "--duration", "0", "https://youtu.be/example")
+	expectParseError(t, "--start must not be negative", "--start", "-1:00", "https://youtu.be/example")
+}
+
+func TestBuildArgsClipLabel(t *testing.T) {
+	cfg := config{Mode: "full", URL: "https://youtu.be/example", Start: "00:00:10", End: "00:00:20", ClipLabel: "Part 2"}
+
+	args, err := buildArgs(cfg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	joined := strings.Join(args, " ")
+	if !strings.Contains(joined, "--download-sections *00:00:10-00:00:20") {
+		t.Fatalf("missing section in %v", args)
+	}
+	if !strings.Contains(joined, "--replace-in-metadata title,track $  (Part 2)") {
+		t.Fatalf("missing title suffix in %v", args)
+	}
+	if !strings.Contains(joined, "--embed-metadata") {
+		t.Fatalf("expected --embed-metadata for video clips in %v", args)
+	}
+}
+
+func TestOutputTemplateClipSuffix(t *testing.T) {
+	cfg := config{Mode: "full", ClipLabel: "Part 2"}
+
+	got, err := outputTemplate("/tmp/ytcli-missing-dir/highlight.mp4", cfg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "/tmp/ytcli-missing-dir/highlight (Part 2).mp4" {
+		t.Fatalf("got %q", got)
+	}
+
+	got, err = outputTemplate("/tmp/ytcli-missing-dir/%(title)s.%(ext)s", cfg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "/tmp/ytcli-missing-dir/%(title)s.%(ext)s" {
+		t.Fatalf("title templates should not get a second suffix, got %q", got)
+	}
+}
+
+func TestExpandClipItems(t *testing.T) {
+	clips := []clipRange{{Start: "00:00:10", Label: "Part 1"}, {Start: "00:01:00", Label: "Part 2"}}
+	items := expandClipItems([]batchItem{{URL: "https://youtu.be/a"}, {URL: "bad", SkipReason: "private video"}}, clips)
+
+	if len(items) != 3 {
+		t.Fatalf("got %d items, want 3", len(items))
+	}
+	if items[1].Part != 2 || items[1].label() != "clip 2" {
+		t.Fatalf("unexpected second item: %+v", items[1])
+	}
+	itemCfg := items[1].config(config{Mode: "audio", Clips: clips})
+	if itemCfg.Start != "00:01:00" || itemCfg.ClipLabel != "Part 2" || itemCfg.Clips != nil {
+		t.Fatalf("unexpected item config: %+v", itemCfg)
+	}
+}
@@ -0,0 +1,305 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/CoastalFuturist/ytcli/internal/buildinfo"
+)
+
+type command struct {
+	Name    string
+	Summary string
+	Run     func(ctx context.Context, args []string, stdout, stderr io.Writer) int
+}
+
+var commands = []command{
+	{Name: "get", Summary: "download media from a url (default when no command is given)", Run: runGetCommand},
+	{Name: "info", Summary: "show metadata and the planned output path without downloading", Run: runInfoCommand},
+	{Name: "tag", Summary: "write tags and cover art to an existing audio file", Run: runTagCommand},
+	{Name: "import", Summary: "import audio files into Apple Music (macOS)", Run: runImportCommand},
+	{Name: "profiles", Summary: "list profiles defined in the config file", Run: runProfilesCommand},
+	{Name: "doctor", Summary: "check external dependencies and configuration", Run: runDoctorCommand},
+	{Name: "version", Summary: "print version and build metadata", Run: runVersionCommand},
+}
+
+func lookupCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.Name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func writeCommandList(w io.Writer) {
+	fmt.Fprintf(w, "Usage:\n  ytcli <command> [flags] [args]\n  ytcli [flags] <url>    (same as ytcli get)\n\nCommands:\n")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", cmd.Name, cmd.Summary)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\nRun 'ytcli <command> -h' for command flags.\n")
+}
+
+// newCommandFlagSet creates a flag set whose usage text starts with the
+// given usage lines and description.
+func newCommandFlagSet(name, usage, description string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("ytcli "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage:\n  %s\n\n%s\n", usage, description)
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintf(stderr, "\nFlags:\n")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+// usageError reports a command-line error and returns the exit code for it.
+func usageError(fs *flag.FlagSet, stderr io.Writer, err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	fmt.Fprintf(stderr, "Error: %v\n", err)
+	if fs != nil {
+		fs.Usage()
+	}
+	return 2
+}
+
+func runVersionCommand(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := newCommandFlagSet("version", "ytcli version", "Print version and build metadata.", stderr)
+	if err := fs.Parse(args); err != nil {
+		return usageError(fs, stderr, err)
+	}
+	if fs.NArg() != 0 {
+		return usageError(fs, stderr, fmt.Errorf("version does not take arguments"))
+	}
+
+	fmt.Fprintln(stdout, buildinfo.String())
+	return 0
+}
+
+func runProfilesCommand(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := newCommandFlagSet("profiles", "ytcli profiles [--config PATH]", "List profiles defined in the config file.", stderr)
+	configPath := fs.String("config", "", "read profiles from this JSON file instead of the user config file")
+	if err := fs.Parse(args); err != nil {
+		return usageError(fs, stderr, err)
+	}
+	if fs.NArg() != 0 {
+		return usageError(fs, stderr, fmt.Errorf("profiles does not take arguments"))
+	}
+
+	file, err := loadConfigFile(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	writeProfiles(stdout, file)
+	return 0
+}
+
+func runTagCommand(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := newCommandFlagSet(
+		"tag",
+		"ytcli tag [--artist NAME] [--song TITLE] [--album NAME] [--year YEAR] [--genre NAME] [--cover FILE] [--square-cover] <file>",
+		"Write tags to an existing audio file. Artist and title not given as flags\nare inferred from an \"Artist - Title\" file name; other existing tags are kept.",
+		stderr,
+	)
+	artist := fs.String("artist", "", "artist tag")
+	song := fs.String("song", "", "song title tag")
+	album := fs.String("album", "", "album tag")
+	year := fs.String("year", "", "release year or date tag (YYYY or YYYY-MM-DD)")
+	genre := fs.String("genre", "", "genre tag")
+	coverPath := fs.String("cover", "", "embed this image as cover art")
+	square := fs.Bool("square-cover", false, "crop the cover art to a centered square")
+	if err := fs.Parse(args); err != nil {
+		return usageError(fs, stderr, err)
+	}
+	if fs.NArg() != 1 {
+		return usageError(fs, stderr, fmt.Errorf("missing required file argument"))
+	}
+	if *song != "" && cleanTitle(*song) == "" {
+		return usageError(fs, stderr, fmt.Errorf("--song must not be empty"))
+	}
+	if err := validateYear(*year); err != nil {
+		return usageError(fs, stderr, err)
+	}
+	path := fs.Arg(0)
+
+	var meta *trackMetadata
+	if inferred, ok := inferTrackMetadataFromPath(path); ok {
+		meta = &inferred
+	}
+	if updated, applied := applyManualMetadata(meta, *artist, *song); applied {
+		meta = updated
+	}
+	if meta == nil || strings.TrimSpace(meta.Title) == "" {
+		fmt.Fprintln(stderr, "Error: could not infer a title from the file name; pass --song")
+		return 1
+	}
+
+	applyTagOverrides(meta, *album, *year, *genre)
+
+	var cover *coverArt
+	if *coverPath != "" {
+		if err := validateCoverOptions(config{Mode: "audio", Cover: *coverPath}); err != nil {
+			return usageError(fs, stderr, err)
+		}
+		if !coverSupported(filepath.Ext(path)) {
+			return usageError(fs, stderr, fmt.Errorf("cover art cannot be embedded in %s files", strings.TrimPrefix(filepath.Ext(path), ".")))
+		}
+		cover = &coverArt{Path: *coverPath, Square: *square}
+	}
+
+	if err := writeAudioMetadata(ctx, path, *meta, cover); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", interruptedError(ctx, err))
+		return failureStatus(ctx)
+	}
+	fmt.Fprintf(stdout, "Tagged audio metadata: %s - %s\n", meta.Artist, meta.Title)
+	if cover != nil {
+		fmt.Fprintf(stdout, "Embedded cover art: %s\n", filepath.Base(cover.Path))
+	}
+	return 0
+}
+
+func runImportCommand(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := newCommandFlagSet("import", "ytcli import <file>...", "Import audio files into the Apple Music library (macOS).", stderr)
+	if err := fs.Parse(args); err != nil {
+		return usageError(fs, stderr, err)
+	}
+	if fs.NArg() == 0 {
+		return usageError(fs, stderr, fmt.Errorf("missing required file argument"))
+	}
+
+	status := 0
+	for _, path := range fs.Args() {
+		if err := importIntoAppleMusic(ctx, path); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", interruptedError(ctx, err))
+			if ctx.Err() != nil {
+				return exitInterrupted
+			}
+			status = 1
+			continue
+		}
+		fmt.Fprintf(stdout, "Imported into Apple Music: %s\n", path)
+	}
+	return status
+}
+
+type doctorCheck struct {
+	Name     string
+	OK       bool
+	Required bool
+	Detail   string
+}
+
+func firstOutputLine(ctx context.Context, name string, args ...string) (string, error) {
+	out, err := runner.Command(ctx, name, args...).Output()
+	if err != nil {
+		return "", err
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return strings.TrimSpace(line), nil
+}
+
+func runDoctorChecks(ctx context.Context) []doctorCheck {
+	checks := []doctorCheck{}
+
+	ytDlp := doctorCheck{Name: "yt-dlp", Required: true}
+	if binary, err := resolveYtDlpBinary(); err != nil {
+		ytDlp.Detail = err.Error()
+	} else if version, err := firstOutputLine(ctx, binary, "--version"); err != nil {
+		ytDlp.Detail = fmt.Sprintf("%s failed to run: %v", binary, err)
+	} else {
+		ytDlp.OK = true
+		ytDlp.Detail = fmt.Sprintf("%s (%s)", binary, version)
+	}
+	checks = append(checks, ytDlp)
+
+	ffmpeg := doctorCheck{Name: "ffmpeg", Required: true}
+	if binary, err := runner.LookPath("ffmpeg"); err != nil {
+		ffmpeg.Detail = "not found in PATH; needed for merging and metadata tagging"
+	} else if version, err := firstOutputLine(ctx, binary, "-version"); err != nil {
+		ffmpeg.Detail = fmt.Sprintf("%s failed to run: %v", binary, err)
+	} else {
+		ffmpeg.OK = true
+		ffmpeg.Detail = fmt.Sprintf("%s (%s)", binary, version)
+	}
+	checks = append(checks, ffmpeg)
+
+	appleMusic := doctorCheck{Name: "apple-music"}
+	if runtime.GOOS != "darwin" {
+		appleMusic.Detail = "unavailable on " + runtime.GOOS + "; --apple-music requires macOS"
+	} else if binary, err := runner.LookPath("osascript"); err != nil {
+		appleMusic.Detail = "osascript not found; --apple-music will fail"
+	} else {
+		appleMusic.OK = true
+		appleMusic.Detail = binary
+	}
+	checks = append(checks, appleMusic)
+
+	configCheck := doctorCheck{Name: "config", Required: true}
+	if file, err := loadConfigFile(""); err != nil {
+		configCheck.Detail = err.Error()
+	} else {
+		configCheck.OK = true
+		configCheck.Detail = file.Path
+		fs := newFlagSet(&config{}, io.Discard)
+		if err := file.apply(fs, file.selectedProfile(fs, "")); err != nil {
+			configCheck.OK = false
+			configCheck.Detail = err.Error()
+		} else if len(file.Values) == 0 && len(file.Profiles) == 0 {
+			configCheck.Detail += " (not present, using built-in defaults)"
+		}
+	}
+	checks = append(checks, configCheck)
+
+	archiveCheck := doctorCheck{Name: "archive"}
+	if dir, err := userDataDir(); err != nil {
+		archiveCheck.Detail = err.Error()
+	} else {
+		archiveCheck.OK = true
+		archiveCheck.Detail = dir
+	}
+	checks = append(checks, archiveCheck)
+
+	return checks
+}
+
+func runDoctorCommand(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := newCommandFlagSet("doctor", "ytcli doctor", "Check external dependencies and configuration.", stderr)
+	if err := fs.Parse(args); err != nil {
+		return usageError(fs, stderr, err)
+	}
+	if fs.NArg() != 0 {
+		return usageError(fs, stderr, fmt.Errorf("doctor does not take arguments"))
+	}
+
+	status := 0
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	for _, check := range runDoctorChecks(ctx) {
+		label := "ok"
+		switch {
+		case !check.OK && check.Required:
+			label = "FAIL"
+			status = 1
+		case !check.OK:
+			label = "warn"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", check.Name, label, check.Detail)
+	}
+	tw.Flush()
+	return status
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/CoastalFuturist/ytcli/internal/buildinfo"
+)
+
+func TestMainRoutesVersionCommand(t *testing.T) {
+	for _, args := range [][]string{{"version"}, {"--version"}, {"get", "--version"}} {
+		var stdout, stderr bytes.Buffer
+		if code := Main(args, &stdout, &stderr); code != 0 {
+			t.Fatalf("%v: exit code %d, stderr %q", args, code, stderr.String())
+		}
+		if strings.TrimSpace(stdout.String()) != buildinfo.String() {
+			t.Fatalf("%v: got %q", args, stdout.String())
+		}
+	}
+}
+
+func TestMainHelpListsCommands(t *testing.T) {
+	var stdout bytes.Buffer
+	if code := Main([]string{"help"}, &stdout, &bytes.Buffer{}); code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	for _, cmd := range commands {
+		if !strings.Contains(stdout.String(), cmd.Name) {
+			t.Fatalf("help output missing %q:\n%s", cmd.Name, stdout.String())
+		}
+	}
+}
+
+func TestMainSubcommandUsageErrors(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"tag"}, wantErr: "missing required file argument"},
+		{args: []string{"import"}, wantErr: "missing required file argument"},
+		{args: []string{"info"}, wantErr: "missing required url argument"},
+		{args: []string{"info", "--audio-format", "flac", "https://youtu.be/example"}, wantErr: "--audio-format is only supported with --mode audio"},
+		{args: []string{"info", "--container", "webm", "--mode", "audio", "https://youtu.be/example"}, wantErr: "only supported with --mode video or full"},
+		{args: []string{"info", "--mode", "video", "--album", "Discovery", "https://youtu.be/example"}, wantErr: "only supported with --mode audio"},
+		{args: []string{"version", "extra"}, wantErr: "does not take arguments"},
+		{args: []string{"get"}, wantErr: "missing required url argument"},
+	}
+
+	for _, tc := range tests {
+		var stderr bytes.Buffer
+		if code := Main(tc.args, &bytes.Buffer{}, &stderr); code != 2 {
+			t.Fatalf("%v: exit code %d, want 2", tc.args, code)
+		}
+		if !strings.Contains(stderr.String(), tc.wantErr) {
+			t.Fatalf("%v: stderr %q does not contain %q", tc.args, stderr.String(), tc.wantErr)
+		}
+	}
+}
+
+func TestInfoCommandIgnoresConfigKeysItDoesNotDefine(t *testing.T) {
+	path := writeConfigFile(t, `{"mode": "audio", "jobs": 4}`)
+
+	var stderr bytes.Buffer
+	Main([]string{"info", "--config", path}, &bytes.Buffer{}, &stderr)
+	if strings.Contains(stderr.String(), "unknown option") {
+		t.Fatalf("info rejected a valid config key: %s", stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "missing required url argument") {
+		t.Fatalf("unexpected stderr: %s", stderr.String())
+	}
+}
+
+func TestProfilesCommand(t *testing.T) {
+	path := writeConfigFile(t, profilesConfig)
+
+	var stdout bytes.Buffer
+	if code := Main([]string{"profiles", "--config", path}, &stdout, &bytes.Buffer{}); code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	if !strings.Contains(stdout.String(), "lecture") || !strings.Contains(stdout.String(), "podcast") {
+		t.Fatalf("unexpected output:\n%s", stdout.String())
+	}
+}
+
+func TestInfoCommandSharesGetOptions(t *testing.T) {
+	path := writeConfigFile(t, `{"vcodec": "h264"}`)
+
+	var stderr bytes.Buffer
+	if code := Main([]string{"info", "--config", path, "--container", "webm", testVideoURL}, &bytes.Buffer{}, &stderr); code != 2 {
+		t.Fatalf("exit code %d, want 2", code)
+	}
+	if !strings.Contains(stderr.String(), "--vcodec h264 cannot be stored in a webm container") {
+		t.Fatalf("info ignored the configured codec: %s", stderr.String())
+	}
+
+	f := useFakeRunner(t)
+	f.ytDlp(t, testVideoInfo, "")
+	var stdout bytes.Buffer
+	stderr.Reset()
+	if code := Main([]string{"info", "--mode", "audio", "--clip", "0:10-0:20:intro", testVideoURL}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Daft Punk - One More Time (intro).mp3") {
+		t.Fatalf("clip label missing from the predicted path:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := Main([]string{"info", "--clip", "0:10-0:20:intro", "--container", "mkv", testVideoURL}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Daft Punk - One More Time (Official Video) (intro) [u9oxz7AQg5c].mkv") {
+		t.Fatalf("clip label or container missing from the predicted path:\n%s", stdout.String())
+	}
+}
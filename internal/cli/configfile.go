@@ -0,0 +1,289 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+const configFileName = "config.json"
+
+// configFileIgnoredFlags cannot be set from a config file.
+var configFileIgnoredFlags = map[string]bool{
+	"config":  true,
+	"dry-run": true,
+	"version": true,
+}
+
+// profileIgnoredFlags additionally cannot be set inside a profile.
+var profileIgnoredFlags = map[string]bool{
+	"profile": true,
+}
+
+type configFile struct {
+	Path     string
+	Values   map[string][]string
+	Profiles map[string]map[string][]string
+}
+
+func userConfigDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "ytcli"), nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve user config directory: %w", err)
+	}
+	return filepath.Join(dir, "ytcli"), nil
+}
+
+func defaultConfigPath() (string, error) {
+	dir, err := userConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configFileName), nil
+}
+
+// configValues converts a JSON value into the flag.Value string forms it
+// stands for. Arrays produce one value per element for repeatable flags.
+func configValues(raw json.RawMessage) ([]string, error) {
+	var decoded any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, err
+	}
+
+	switch v := decoded.(type) {
+	case string:
+		return []string{v}, nil
+	case bool, float64:
+		return []string{strings.TrimSpace(string(raw))}, nil
+	case []any:
+		values := []string{}
+		for _, elem := range v {
+			switch elem.(type) {
+			case string, bool, float64:
+				values = append(values, fmt.Sprint(elem))
+			default:
+				return nil, fmt.Errorf("array elements must be strings, numbers, or booleans")
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("must be a string, number, boolean, or array")
+}
+
+func parseConfigValues(raw map[string]json.RawMessage) (map[string][]string, error) {
+	values := map[string][]string{}
+	for key, value := range raw {
+		converted, err := configValues(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid config value for %q: %w", key, err)
+		}
+		values[key] = converted
+	}
+	return values, nil
+}
+
+// parseConfigFile decodes a JSON object whose keys are flag names, plus an
+// optional "profiles" object mapping profile names to objects of the same
+// shape.
+func parseConfigFile(data []byte) (*configFile, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid config file: %w", err)
+	}
+
+	file := &configFile{Profiles: map[string]map[string][]string{}}
+	if rawProfiles, ok := raw["profiles"]; ok {
+		delete(raw, "profiles")
+		var profiles map[string]map[string]json.RawMessage
+		if err := json.Unmarshal(rawProfiles, &profiles); err != nil {
+			return nil, fmt.Errorf("invalid profiles: %w", err)
+		}
+		for name, rawValues := range profiles {
+			values, err := parseConfigValues(rawValues)
+			if err != nil {
+				return nil, fmt.Errorf("profile %q: %w", name, err)
+			}
+			file.Profiles[name] = values
+		}
+	}
+
+	values, err := parseConfigValues(raw)
+	if err != nil {
+		return nil, err
+	}
+	file.Values = values
+	return file, nil
+}
+
+// loadConfigFile reads path, or the default config path when path is empty.
+// A missing default config file yields an empty config; a missing explicit
+// one is an error.
+func loadConfigFile(path string) (*configFile, error) {
+	explicit := path != ""
+	if !explicit {
+		defaultPath, err := defaultConfigPath()
+		if err != nil {
+			return nil, err
+		}
+		path = defaultPath
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return &configFile{Path: path}, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	file, err := parseConfigFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	file.Path = path
+	return file, nil
+}
+
+func (c *configFile) profileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// selectedProfile returns the profile named on the command line, falling back
+// to a "profile" key in the config file.
+func (c *configFile) selectedProfile(fs *flag.FlagSet, flagValue string) string {
+	explicit := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "profile" {
+			explicit = true
+		}
+	})
+	if explicit {
+		return flagValue
+	}
+	if values := c.Values["profile"]; len(values) > 0 {
+		return values[len(values)-1]
+	}
+	return ""
+}
+
+// apply layers the selected profile and then the top-level config values
+// under the command-line flags. Each layer only fills flags that are still
+// unset, so precedence is flags, then profile, then top-level defaults.
+func (c *configFile) apply(fs *flag.FlagSet, profile string) error {
+	if profile != "" {
+		values, ok := c.Profiles[profile]
+		if !ok {
+			available := "none defined"
+			if names := c.profileNames(); len(names) > 0 {
+				available = strings.Join(names, ", ")
+			}
+			return fmt.Errorf("unknown profile %q (available: %s)", profile, available)
+		}
+		for key := range values {
+			if profileIgnoredFlags[key] {
+				return fmt.Errorf("%s: profile %q: unknown option %q", c.Path, profile, key)
+			}
+		}
+		if err := applyConfigDefaults(fs, values, fmt.Sprintf("%s: profile %q", c.Path, profile)); err != nil {
+			return err
+		}
+	}
+	return applyConfigDefaults(fs, c.Values, c.Path)
+}
+
+func formatProfileSettings(values map[string][]string) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := []string{}
+	for _, key := range keys {
+		for _, value := range values[key] {
+			parts = append(parts, key+"="+value)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func writeProfiles(w io.Writer, c *configFile) {
+	names := c.profileNames()
+	if len(names) == 0 {
+		fmt.Fprintf(w, "No profiles defined in %s\n", c.Path)
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROFILE\tSETTINGS")
+	for _, name := range names {
+		fmt.Fprintf(tw, "%s\t%s\n", name, formatProfileSettings(c.Profiles[name]))
+	}
+	tw.Flush()
+}
+
+// applyUserConfig loads the config file (the default one when configPath is
+// empty) and layers it and the selected profile under the parsed flags.
+func applyUserConfig(fs *flag.FlagSet, configPath, profile string) error {
+	file, err := loadConfigFile(configPath)
+	if err != nil {
+		return err
+	}
+	return file.apply(fs, file.selectedProfile(fs, profile))
+}
+
+// isConfigurableFlag reports whether name is a `get` flag that a config file
+// may set. Config files are shared by every command, so keys are validated
+// against the full `get` flag set even when applied to a smaller one.
+func isConfigurableFlag(name string) bool {
+	if configFileIgnoredFlags[name] {
+		return false
+	}
+	return newFlagSet(&config{}, io.Discard).Lookup(name) != nil
+}
+
+// applyConfigDefaults sets every flag named in values that was not given on
+// the command line, so explicit flags always take precedence. Keys that are
+// valid config options but not defined by fs are skipped.
+func applyConfigDefaults(fs *flag.FlagSet, values map[string][]string, source string) error {
+	explicit := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !isConfigurableFlag(key) {
+			return fmt.Errorf("%s: unknown option %q", source, key)
+		}
+		if fs.Lookup(key) == nil || explicit[key] {
+			continue
+		}
+		for _, value := range values[key] {
+			if err := fs.Set(key, value); err != nil {
+				return fmt.Errorf("%s: invalid value %q for %q: %w", source, value, key, err)
+			}
+		}
+	}
+	return nil
+}
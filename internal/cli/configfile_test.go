@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain points the user config and data directories at a scratch
+// location so a developer's own config file or archive never leaks into tests.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "ytcli-test-")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	os.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseConfigUsesConfigFileDefaults(t *testing.T) {
+	path := writeConfigFile(t, `{"mode": "audio", "output": "~/Music", "jobs": 3, "no-archive": true}`)
+
+	cfg, _, err := parseConfig([]string{"--config", path, "https://youtu.be/example"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Mode != "audio" || cfg.Output != "~/Music" || cfg.Jobs != 3 || !cfg.NoArchive {
+		t.Fatalf("config defaults not applied: %+v", cfg)
+	}
+}
+
+func TestParseConfigFlagsOverrideConfigFile(t *testing.T) {
+	path := writeConfigFile(t, `{"mode": "audio", "apple-music": true}`)
+
+	cfg, _, err := parseConfig([]string{"--config", path, "--mode", "full", "--apple-music=false", "https://youtu.be/example"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Mode != "full" {
+		t.Fatalf("mode got %q, want %q", cfg.Mode, "full")
+	}
+	if cfg.AppleMusic {
+		t.Fatal("expected --apple-music=false to override config file")
+	}
+}
+
+func TestParseConfigReadsDefaultConfigPath(t *testing.T) {
+	path, err := defaultConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"mode": "video"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(path)
+
+	cfg, _, err := parseConfig([]string{"https://youtu.be/example"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Mode != "video" {
+		t.Fatalf("mode got %q, want %q", cfg.Mode, "video")
+	}
+}
+
+func TestParseConfigRejectsBadConfigFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "unknown key", content: `{"colour": "blue"}`, wantErr: `unknown option "colour"`},
+		{name: "nested config", content: `{"config": "other.json"}`, wantErr: `unknown option "config"`},
+		{name: "bad value", content: `{"jobs": "many"}`, wantErr: `invalid value "many"`},
+		{name: "object value", content: `{"mode": {"x": 1}}`, wantErr: `invalid config value`},
+		{name: "malformed", content: `{`, wantErr: "invalid config file"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeConfigFile(t, tc.content)
+			_, _, err := parseConfig([]string{"--config", path, "https://youtu.be/example"}, &bytes.Buffer{})
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("error %q does not contain %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseConfigRejectsMissingExplicitConfigFile(t *testing.T) {
+	_, _, err := parseConfig([]string{"--config", filepath.Join(t.TempDir(), "missing.json"), "https://youtu.be/example"}, &bytes.Buffer{})
+	if err == nil {
+		t.Fatal("expected error for missing --config file")
+	}
+}
+
+const profilesConfig = `{
+	"mode": "full",
+	"output": "~/Videos",
+	"profile": "lecture",
+	"profiles": {
+		"podcast": {"mode": "audio", "output": "~/Podcasts"},
+		"lecture": {"mode": "video"}
+	}
+}`
+
+func TestParseConfigAppliesProfile(t *testing.T) {
+	path := writeConfigFile(t, profilesConfig)
+
+	cfg, _, err := parseConfig([]string{"--config", path, "--profile", "podcast", "https://youtu.be/example"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Mode != "audio" || cfg.Output != "~/Podcasts" {
+		t.Fatalf("profile not applied: mode=%q output=%q", cfg.Mode, cfg.Output)
+	}
+
+	cfg, _, err = parseConfig([]string{"--config", path, "--profile", "podcast", "--output", "/tmp", "https://youtu.be/example"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Output != "/tmp" {
+		t.Fatalf("flag should override profile, got output %q", cfg.Output)
+	}
+}
+
+func TestParseConfigUsesDefaultProfileFromConfigFile(t *testing.T) {
+	path := writeConfigFile(t, profilesConfig)
+
+	cfg, _, err := parseConfig([]string{"--config", path, "https://youtu.be/example"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Mode != "video" {
+		t.Fatalf("mode got %q, want profile value %q", cfg.Mode, "video")
+	}
+	if cfg.Output != "~/Videos" {
+		t.Fatalf("output got %q, want top-level value %q", cfg.Output, "~/Videos")
+	}
+}
+
+func TestParseConfigRejectsUnknownProfile(t *testing.T) {
+	path := writeConfigFile(t, profilesConfig)
+
+	_, _, err := parseConfig([]string{"--config", path, "--profile", "music", "https://youtu.be/example"}, &bytes.Buffer{})
+	if err == nil {
+		t.Fatal("expected error for unknown profile")
+	}
+	if !strings.Contains(err.Error(), "available: lecture, podcast") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestWriteProfiles(t *testing.T) {
+	file, err := parseConfigFile([]byte(profilesConfig))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	writeProfiles(&buf, file)
+	out := buf.String()
+	if !strings.Contains(out, "podcast") || !strings.Contains(out, "mode=audio output=~/Podcasts") {
+		t.Fatalf("unexpected profiles output:\n%s", out)
+	}
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// coverArt is an image embedded as the front cover while tagging.
+type coverArt struct {
+	Path   string `json:"path"`
+	Square bool   `json:"square,omitempty"`
+}
+
+// coverSupported reports whether ffmpeg can attach a cover picture to an
+// audio file with extension ext. Ogg and RIFF have no attached-picture
+// stream for ffmpeg to write.
+func coverSupported(ext string) bool {
+	switch strings.ToLower(strings.TrimPrefix(ext, ".")) {
+	case "mp3", "m4a", "flac":
+		return true
+	}
+	return false
+}
+
+// thumbnailArgs asks yt-dlp to save the video thumbnail as jpg next to the
+// download so it can be embedded as cover art. yt-dlp also prints where it
+// wrote the thumbnail, before converting it, so an interrupted run can remove
+// it.
+func thumbnailArgs(cfg config) []string {
+	if cfg.Mode != "audio" || cfg.NoCover || cfg.Cover != "" {
+		return nil
+	}
+	return []string{
+		"--write-thumbnail", "--convert-thumbnails", "jpg",
+		"--print", "before_dl:" + thumbnailPrefix + "%(thumbnails.-1.filepath)s",
+	}
+}
+
+// thumbnailPath is where yt-dlp leaves the converted thumbnail for the
+// download at path: the same name with a .jpg extension.
+func thumbnailPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".jpg"
+}
+
+// coverArgs maps the audio of the first ffmpeg input and cover, the second
+// input, as the attached front cover. The picture is copied when the
+// container can store it as is and re-encoded to jpeg otherwise.
+func coverArgs(cover coverArt) []string {
+	args := []string{"-map", "0:a", "-map", "1:v", "-c", "copy"}
+	ext := strings.ToLower(filepath.Ext(cover.Path))
+	if cover.Square || (ext != ".jpg" && ext != ".jpeg" && ext != ".png") {
+		args = append(args, "-c:v", "mjpeg", "-q:v", "2")
+	}
+	if cover.Square {
+		// YouTube thumbnails are 16:9; Topic channel art sits letterboxed in
+		// the middle, so a centered crop recovers the square artwork.
+		args = append(args, "-filter:v", "crop='min(iw,ih)':'min(iw,ih)'")
+	}
+	return append(args,
+		"-disposition:v:0", "attached_pic",
+		"-metadata:s:v", "title=Album cover",
+		"-metadata:s:v", "comment=Cover (front)",
+	)
+}
+
+// resolveCover picks the cover for the download at path: --cover FILE, or
+// the thumbnail yt-dlp wrote. The returned cleanup path is the thumbnail to
+// remove once tagging is done, if any. A nil cover means none is embedded;
+// the reason is reported through warn.
+func resolveCover(cfg config, path string, warn func(format string, args ...any)) (cover *coverArt, cleanup string) {
+	if cfg.NoCover {
+		return nil, ""
+	}
+	source := cfg.Cover
+	if source == "" {
+		cleanup = thumbnailPath(path)
+		source = cleanup
+		if _, err := os.Stat(source); err != nil {
+			warn("video thumbnail unavailable, skipping cover art")
+			return nil, ""
+		}
+	}
+	if !coverSupported(filepath.Ext(path)) {
+		warn("cover art cannot be embedded in %s files, skipping it", strings.TrimPrefix(filepath.Ext(path), "."))
+		return nil, cleanup
+	}
+	return &coverArt{Path: source, Square: cfg.SquareCover}, cleanup
+}
+
+// resolveCoverStep picks the cover embedded by the tagging or chapter step
+// and schedules the thumbnail for removal.
+func resolveCoverStep(ctx context.Context, job *postJob) error {
+	cover, thumbnail := resolveCover(job.Cfg, job.Path, job.warn)
+	if thumbnail != "" {
+		job.cleanup = append(job.cleanup, thumbnail)
+	}
+	job.Cover = cover
+	return nil
+}
+
+func validateCoverOptions(cfg config) error {
+	if cfg.Mode != "audio" && (cfg.Cover != "" || cfg.NoCover || cfg.SquareCover) {
+		return fmt.Errorf("--cover, --no-cover and --square-cover are only supported with --mode audio")
+	}
+	if cfg.Cover != "" && cfg.NoCover {
+		return fmt.Errorf("--cover cannot be combined with --no-cover")
+	}
+	if cfg.Cover != "" {
+		info, err := os.Stat(cfg.Cover)
+		if err != nil {
+			return fmt.Errorf("--cover: %w", err)
+		}
+		if info.IsDir() {
+			return fmt.Errorf("--cover: %s is a directory", cfg.Cover)
+		}
+	}
+	return nil
+}
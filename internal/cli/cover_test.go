@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCoverArgs(t *testing.T) {
+	tests := []struct {
+		cover coverArt
+		want  string
+	}{
+		{
+			coverArt{Path: "/tmp/a.jpg"},
+			"-map 0:a -map 1:v -c copy -disposition:v:0 attached_pic -metadata:s:v title=Album cover -metadata:s:v comment=Cover (front)",
+		},
+		{
+			coverArt{Path: "/tmp/a.webp"},
+			"-map 0:a -map 1:v -c copy -c:v mjpeg -q:v 2 -disposition:v:0 attached_pic -metadata:s:v title=Album cover -metadata:s:v comment=Cover (front)",
+		},
+		{
+			coverArt{Path: "/tmp/a.PNG", Square: true},
+			"-map 0:a -map 1:v -c copy -c:v mjpeg -q:v 2 -filter:v crop='min(iw,ih)':'min(iw,ih)' -disposition:v:0 attached_pic -metadata:s:v title=Album cover -metadata:s:v comment=Cover (front)",
+		},
+	}
+	for _, tc := range tests {
+		if got := strings.Join(coverArgs(tc.cover), " "); got != tc.want {
+			t.Fatalf("%+v: got %q, want %q", tc.cover, got, tc.want)
+		}
+	}
+}
+
+func TestResolveCover(t *testing.T) {
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "Artist - Title.mp3")
+	var warnings []string
+	warn := func(format string, args ...any) { warnings = append(warnings, fmt.Sprintf(format, args...)) }
+
+	cover, cleanup := resolveCover(config{Mode: "audio"}, audio, warn)
+	if cover != nil || cleanup != "" || len(warnings) != 1 || !strings.Contains(warnings[0], "thumbnail unavailable") {
+		t.Fatalf("missing thumbnail: got %+v %q %v", cover, cleanup, warnings)
+	}
+
+	thumb := filepath.Join(dir, "Artist - Title.jpg")
+	if err := os.WriteFile(thumb, []byte("jpg"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cover, cleanup = resolveCover(config{Mode: "audio", SquareCover: true}, audio, warn)
+	if cover == nil || cover.Path != thumb || !cover.Square || cleanup != thumb {
+		t.Fatalf("thumbnail: got %+v %q", cover, cleanup)
+	}
+
+	cover, cleanup = resolveCover(config{Mode: "audio", Cover: "/art/front.png"}, audio, warn)
+	if cover == nil || cover.Path != "/art/front.png" || cleanup != "" {
+		t.Fatalf("--cover: got %+v %q", cover, cleanup)
+	}
+
+	warnings = nil
+	cover, cleanup = resolveCover(config{Mode: "audio"}, filepath.Join(dir, "Artist - Title.opus"), warn)
+	if cover != nil || len(warnings) != 1 || !strings.Contains(warnings[0], "opus") {
+		t.Fatalf("opus: got %+v %v", cover, warnings)
+	}
+
+	if cover, _ := resolveCover(config{Mode: "audio", NoCover: true}, audio, warn); cover != nil {
+		t.Fatalf("--no-cover: got %+v", cover)
+	}
+}
+
+func TestParseConfigCover(t *testing.T) {
+	cfg, _, err := parseConfig([]string{"--mode", "audio", "https://youtu.be/example"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	args, err := buildArgs(cfg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(strings.Join(args, " "), "--write-thumbnail --convert-thumbnails jpg") {
+		t.Fatalf("expected thumbnail args in %v", args)
+	}
+
+	cfg.NoCover = true
+	args, _ = buildArgs(cfg, nil)
+	if strings.Contains(strings.Join(args, " "), "--write-thumbnail") {
+		t.Fatalf("unexpected thumbnail args with --no-cover: %v", args)
+	}
+
+	expectParseError(t, "only supported with --mode audio", "--no-cover", "https://youtu.be/example")
+	expectParseError(t, "cannot be combined", "--mode", "audio", "--cover", "cover_test.go", "--no-cover", "https://youtu.be/example")
+	expectParseError(t, "--cover:", "--mode", "audio", "--cover", "missing.jpg", "https://youtu.be/example")
+}
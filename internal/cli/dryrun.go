@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// reShellSafe matches words a POSIX shell reads literally.
+var reShellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote joins args into one copy-pasteable shell command line.
+func shellQuote(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if reShellSafe.MatchString(arg) {
+			quoted[i] = arg
+		} else {
+			quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// dryRun prints the commands run would execute for a download whose yt-dlp
+// arguments are args. The output path is predicted from the video info, so
+// the post-processing commands use the file name yt-dlp would produce;
+// values that depend on an analysis pass are shown as <placeholders>.
+func dryRun(ctx context.Context, result *downloadResult, cfg config, ytDlpBinary string, args []string, meta *trackMetadata, info *videoInfo, stdout, stderr io.Writer) error {
+	commands := [][]string{append([]string{ytDlpBinary}, args...)}
+
+	if info == nil {
+		var err error
+		info, err = fetchVideoInfo(ctx, ytDlpBinary, cfg.URL)
+		if err != nil {
+			result.warn(stderr, "could not predict the output path, post-processing commands are not shown (%v)", err)
+		}
+	}
+	if info != nil {
+		template, err := outputTemplate(cfg.Output, cfg, meta)
+		if err != nil {
+			return withKind(errKindConfig, err)
+		}
+		path, err := filepath.Abs(predictOutputPath(cfg, info, template))
+		if err != nil {
+			return withKind(errKindInternal, fmt.Errorf("failed to resolve output path: %w", err))
+		}
+		chapterInfo := info
+		if !cfg.Split || len(info.Chapters) == 0 {
+			chapterInfo = nil
+		}
+		job := &postJob{
+			Cfg:    cfg,
+			Path:   path,
+			Files:  []string{path},
+			Meta:   meta,
+			Info:   chapterInfo,
+			Result: result,
+			Stdout: stdout,
+			Stderr: stderr,
+		}
+		commands = append(commands, newPostPipeline().plan(job)...)
+		result.Path = job.Path
+		result.setMetadata(job.Meta)
+		fmt.Fprintf(stdout, "Output: %s\n", job.Path)
+	}
+
+	for _, command := range commands {
+		line := shellQuote(command)
+		result.Commands = append(result.Commands, line)
+		fmt.Fprintln(stdout, line)
+	}
+	fmt.Fprintln(stdout, "Dry run: nothing was downloaded or changed.")
+	return nil
+}
+
+// tempPlaceholder stands for the temporary file a step writes next to path
+// before replacing it; the real name ends in a random number.
+func tempPlaceholder(path, kind string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + ".ytcli-" + kind + "-XXXXXX" + ext
+}
+
+func planEffects(job *postJob) [][]string {
+	cfg := job.Cfg
+	start := ""
+	if cfg.TrimSilence {
+		start = "<start>"
+	}
+	var audioFilters, videoFilters []string
+	for _, fade := range []struct{ kind, value string }{{"in", cfg.FadeIn}, {"out", cfg.FadeOut}} {
+		if fade.value == "" {
+			continue
+		}
+		seconds, _ := parseTimestampSeconds(strings.TrimSpace(fade.value))
+		st := "0"
+		if fade.kind == "out" {
+			st = "<length-" + formatSeconds(seconds) + ">"
+		}
+		spec := fmt.Sprintf("t=%s:st=%s:d=%s", fade.kind, st, formatSeconds(seconds))
+		audioFilters = append(audioFilters, "afade="+spec)
+		videoFilters = append(videoFilters, "fade="+spec)
+	}
+	return [][]string{
+		append([]string{"ffmpeg"}, silenceAnalysisArgs(job.Path, cfg)...),
+		append([]string{"ffmpeg"}, effectsArgs(job.Path, tempPlaceholder(job.Path, "effects"), cfg, start, "<length>", audioFilters, videoFilters)...),
+	}
+}
+
+func planNormalize(job *postJob) [][]string {
+	measured := loudnessStats{
+		InputI:       "<input_i>",
+		InputTP:      "<input_tp>",
+		InputLRA:     "<input_lra>",
+		InputThresh:  "<input_thresh>",
+		TargetOffset: "<target_offset>",
+	}
+	return [][]string{
+		append([]string{"ffmpeg"}, loudnessAnalysisArgs([]string{job.Path}, job.Cfg.TargetLUFS)...),
+		append([]string{"ffmpeg"}, loudnessCorrectionArgs(job.Path, tempPlaceholder(job.Path, "normalize"), job.Cfg.TargetLUFS, measured, "<sample_rate>")...),
+	}
+}
+
+// planCover picks the cover the way resolveCoverStep does, assuming yt-dlp
+// writes the thumbnail.
+func planCover(job *postJob) [][]string {
+	cfg := job.Cfg
+	if cfg.NoCover {
+		return nil
+	}
+	if !coverSupported(filepath.Ext(job.Path)) {
+		job.warn("cover art cannot be embedded in %s files, skipping it", strings.TrimPrefix(filepath.Ext(job.Path), "."))
+		return nil
+	}
+	source := cfg.Cover
+	if source == "" {
+		source = thumbnailPath(job.Path)
+	}
+	job.Cover = &coverArt{Path: source, Square: cfg.SquareCover}
+	return nil
+}
+
+func planChapterSplit(job *postJob) [][]string {
+	dir, parts := planChapters(job.Path, job.Info, job.Cfg)
+	commands := make([][]string, 0, len(parts))
+	files := make([]string, 0, len(parts))
+	for _, part := range parts {
+		commands = append(commands, append([]string{"ffmpeg"}, chapterArgs(job.Path, part.Out, part.Chapter, part.Meta, job.Cover)...))
+		files = append(files, part.Out)
+	}
+	job.Path = dir
+	job.Files = files
+	job.Result.Files = files
+	job.Meta = &trackMetadata{
+		Artist: chapterAlbumArtist(job.Info, job.Cfg.Artist),
+		Title:  chapterAlbum(job.Info, job.Cfg.Album),
+	}
+	return commands
+}
+
+func planTag(job *postJob) [][]string {
+	meta, err := prepareTags(job)
+	if err != nil {
+		job.warn("%v", err)
+	}
+	if meta == nil {
+		return nil
+	}
+	return [][]string{append([]string{"ffmpeg"}, tagArgs(job.Path, tempPlaceholder(job.Path, "tagging"), *meta, job.Cover)...)}
+}
+
+func planReplayGain(job *postJob) [][]string {
+	var commands [][]string
+	files := replayGainFiles(job.Files, job.warn)
+	tags := []string{"REPLAYGAIN_TRACK_GAIN=<track_gain>", "REPLAYGAIN_TRACK_PEAK=<track_peak>"}
+	if len(files) > 1 {
+		commands = append(commands, append([]string{"ffmpeg"}, loudnessAnalysisArgs(files, replayGainReference)...))
+		tags = append(tags, "REPLAYGAIN_ALBUM_GAIN=<album_gain>", "REPLAYGAIN_ALBUM_PEAK=<album_peak>")
+	}
+	for _, path := range files {
+		commands = append(commands,
+			append([]string{"ffmpeg"}, loudnessAnalysisArgs([]string{path}, replayGainReference)...),
+			append([]string{"ffmpeg"}, replayGainTagArgs(path, tempPlaceholder(path, "tagging"), tags)...),
+		)
+	}
+	return commands
+}
+
+func planImport(job *postJob) [][]string {
+	commands := make([][]string, 0, len(job.Files))
+	for _, path := range job.Files {
+		commands = append(commands, append([]string{"osascript"}, appleMusicImportArgs(path)...))
+	}
+	return commands
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	got := shellQuote([]string{"yt-dlp", "-o", "/music/AC/DC - It's a Long Way.%(ext)s", "--print", "after_move:x", "<start>", ""})
+	want := `yt-dlp -o '/music/AC/DC - It'\''s a Long Way.%(ext)s' --print after_move:x '<start>' ''`
+	if got != want {
+		t.Fatalf("got %s\nwant %s", got, want)
+	}
+}
+
+func TestTempPlaceholder(t *testing.T) {
+	if got := tempPlaceholder("/music/Daft Punk - One More Time.mp3", "tagging"); got != "/music/Daft Punk - One More Time.ytcli-tagging-XXXXXX.mp3" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestPostPipelinePlan(t *testing.T) {
+	cfg := config{URL: "https://youtu.be/example", Mode: "audio", Normalize: true, TargetLUFS: -14, ReplayGain: true, AppleMusic: true, Year: "2001"}
+	var stderr bytes.Buffer
+	job := &postJob{
+		Cfg:    cfg,
+		Path:   "/music/Daft Punk - One More Time.mp3",
+		Files:  []string{"/music/Daft Punk - One More Time.mp3"},
+		Meta:   &trackMetadata{Artist: "Daft Punk", Title: "One More Time"},
+		Result: &downloadResult{},
+		Stderr: &stderr,
+	}
+
+	var lines []string
+	for _, command := range newPostPipeline().plan(job) {
+		lines = append(lines, shellQuote(command))
+	}
+	if len(lines) != 6 {
+		t.Fatalf("expected 6 commands, got %d:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+	wantPrefixes := []string{
+		"ffmpeg -hide_banner -nostdin -i '/music/Daft Punk - One More Time.mp3' -map 0:a:0 -af loudnorm=I=-14:TP=-1.0:LRA=11:print_format=json -f null -",
+		"ffmpeg -hide_banner -loglevel error -nostdin -y -i '/music/Daft Punk - One More Time.mp3' -map 0 -map_metadata 0 -c copy -af 'loudnorm=I=-14:TP=-1.0:LRA=11:measured_I=<input_i>:",
+		"ffmpeg -hide_banner -loglevel error -nostdin -y -i '/music/Daft Punk - One More Time.mp3' -i '/music/Daft Punk - One More Time.jpg' -map 0:a -map 1:v",
+		"ffmpeg -hide_banner -nostdin -i '/music/Daft Punk - One More Time.mp3' -map 0:a:0 -af loudnorm=I=-18:",
+		"ffmpeg -hide_banner -loglevel error -nostdin -y -i '/music/Daft Punk - One More Time.mp3' -map 0 -map_metadata 0 -c copy -id3v2_version 3 -metadata 'REPLAYGAIN_TRACK_GAIN=<track_gain>'",
+		"osascript -e '",
+	}
+	for i, prefix := range wantPrefixes {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Fatalf("command %d:\n got %s\nwant prefix %s", i, lines[i], prefix)
+		}
+	}
+	if !strings.Contains(lines[2], "date=2001") || !strings.HasSuffix(lines[2], "'/music/Daft Punk - One More Time.ytcli-tagging-XXXXXX.mp3'") {
+		t.Fatalf("unexpected tagging command: %s", lines[2])
+	}
+	if !strings.HasSuffix(lines[5], "' '/music/Daft Punk - One More Time.mp3'") {
+		t.Fatalf("unexpected import command: %s", lines[5])
+	}
+}
+
+func TestPlanChapterSplit(t *testing.T) {
+	info, err := parseVideoInfo([]byte(chapteredInfoJSON))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	job := &postJob{
+		Cfg:    config{URL: "https://youtu.be/set123", Mode: "audio", Split: true, NoCover: true},
+		Path:   "/music/Some DJ - Boiler Room Set (Full Album).mp3",
+		Info:   info,
+		Result: &downloadResult{},
+	}
+	commands := newPostPipeline().plan(job)
+	if len(commands) != 3 {
+		t.Fatalf("expected one command per chapter, got %v", commands)
+	}
+	if job.Path != "/music/Boiler Room Set (Full Album)" || len(job.Files) != 3 || job.Files[1] != "/music/Boiler Room Set (Full Album)/02 - Daft Punk - One More Time.mp3" {
+		t.Fatalf("unexpected job after plan: %q %v", job.Path, job.Files)
+	}
+	if got := shellQuote(commands[1]); !strings.Contains(got, "-ss 60.000 -t 240.000") {
+		t.Fatalf("unexpected chapter command: %s", got)
+	}
+}
+
+func TestParseConfigDryRun(t *testing.T) {
+	cfg, _, err := parseConfig([]string{"--dry-run", "--mode", "audio", "https://youtu.be/example"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.DryRun {
+		t.Fatalf("expected dry run, got %+v", cfg)
+	}
+	if isConfigurableFlag("dry-run") {
+		t.Fatalf("dry-run must not be settable from the config file")
+	}
+}
@@ -0,0 +1,252 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultSilenceThreshold = -50.0
+	// minSilence is the shortest quiet stretch treated as silence, so short
+	// pauses inside the audio are never mistaken for a lead-in.
+	minSilence = 0.5
+	// silenceTolerance is how close to either end a silence must reach to be
+	// trimmed.
+	silenceTolerance = 0.05
+)
+
+var (
+	reSilenceStart = regexp.MustCompile(`silence_start: (-?[\d.]+)`)
+	reSilenceEnd   = regexp.MustCompile(`silence_end: (-?[\d.]+)`)
+	reDuration     = regexp.MustCompile(`Duration: (\d+):(\d{2}):(\d{2}(?:\.\d+)?)`)
+)
+
+// silenceSpan is one stretch silencedetect reported. End is negative while
+// the silence lasts until the end of the input.
+type silenceSpan struct {
+	Start float64
+	End   float64
+}
+
+// hasEffects reports whether any trim or fade option is set.
+func (c config) hasEffects() bool {
+	return c.TrimSilence || c.FadeIn != "" || c.FadeOut != ""
+}
+
+func validateEffectOptions(cfg config) error {
+	if cfg.SilenceThreshold != defaultSilenceThreshold && !cfg.TrimSilence {
+		return fmt.Errorf("--silence-threshold requires --trim-silence")
+	}
+	if cfg.SilenceThreshold < -100 || cfg.SilenceThreshold >= 0 {
+		return fmt.Errorf("--silence-threshold must be between -100 and 0 dB")
+	}
+	for _, fade := range []struct{ flag, value string }{{"--fade-in", cfg.FadeIn}, {"--fade-out", cfg.FadeOut}} {
+		if fade.value == "" {
+			continue
+		}
+		if seconds, ok := parseTimestampSeconds(strings.TrimSpace(fade.value)); !ok || seconds <= 0 {
+			return fmt.Errorf("invalid %s duration %q; use seconds, MM:SS or 1m30s", fade.flag, fade.value)
+		}
+	}
+	if !cfg.hasEffects() {
+		return nil
+	}
+	if cfg.Mode == "video" {
+		return fmt.Errorf("--trim-silence, --fade-in and --fade-out require --mode audio or full")
+	}
+	if cfg.Split {
+		return fmt.Errorf("--trim-silence, --fade-in and --fade-out cannot be combined with --split-chapters")
+	}
+	return nil
+}
+
+// parseSilenceDetect reads the silences and the input duration from the
+// output of an ffmpeg silencedetect pass.
+func parseSilenceDetect(output string) ([]silenceSpan, float64) {
+	var spans []silenceSpan
+	var duration float64
+	for _, line := range strings.Split(output, "\n") {
+		if m := reDuration.FindStringSubmatch(line); m != nil && duration == 0 {
+			hours, _ := strconv.ParseFloat(m[1], 64)
+			minutes, _ := strconv.ParseFloat(m[2], 64)
+			seconds, _ := strconv.ParseFloat(m[3], 64)
+			duration = hours*3600 + minutes*60 + seconds
+		}
+		if m := reSilenceStart.FindStringSubmatch(line); m != nil {
+			start, _ := strconv.ParseFloat(m[1], 64)
+			spans = append(spans, silenceSpan{Start: start, End: -1})
+		}
+		if m := reSilenceEnd.FindStringSubmatch(line); m != nil && len(spans) > 0 {
+			spans[len(spans)-1].End, _ = strconv.ParseFloat(m[1], 64)
+		}
+	}
+	return spans, duration
+}
+
+// audibleRange returns the part of a duration-long input that remains once
+// silence touching either end is cut off.
+func audibleRange(spans []silenceSpan, duration float64) (float64, float64) {
+	start, end := 0.0, duration
+	if len(spans) == 0 {
+		return start, end
+	}
+	if first := spans[0]; first.Start <= silenceTolerance && first.End > 0 {
+		start = first.End
+	}
+	if last := spans[len(spans)-1]; last.End < 0 || last.End >= duration-silenceTolerance {
+		if last.Start > start {
+			end = last.Start
+		}
+	}
+	return start, end
+}
+
+// fadeFilters returns the afade and, for video, fade filters for a
+// length-second output. Fades longer than the output are shortened to it.
+func fadeFilters(kind string, fadeIn, fadeOut, length float64) []string {
+	var filters []string
+	if fadeIn > 0 {
+		filters = append(filters, fmt.Sprintf("%s=t=in:st=0:d=%s", kind, formatSeconds(min(fadeIn, length))))
+	}
+	if fadeOut > 0 {
+		fadeOut = min(fadeOut, length)
+		filters = append(filters, fmt.Sprintf("%s=t=out:st=%s:d=%s", kind, formatSeconds(length-fadeOut), formatSeconds(fadeOut)))
+	}
+	return filters
+}
+
+// videoEncoderArgs picks the encoder for re-encoded video: the --vcodec
+// choice, or the usual codec of the container.
+func videoEncoderArgs(cfg config) []string {
+	codec := cfg.VCodec
+	if codec == "" && cfg.container() == "webm" {
+		codec = "vp9"
+	}
+	switch codec {
+	case "vp9":
+		return []string{"-c:v", "libvpx-vp9", "-crf", "31", "-b:v", "0"}
+	case "av1":
+		return []string{"-c:v", "libsvtav1", "-crf", "30"}
+	default:
+		return []string{"-c:v", "libx264", "-crf", "18", "-preset", "medium"}
+	}
+}
+
+// applyEffects trims silence from both ends of path and applies the fades
+// of cfg in place. A first pass measures the duration and, with
+// --trim-silence, the silences; the second cuts and fades while copying the
+// tags. In full mode the video is cut and faded with the audio.
+func applyEffects(ctx context.Context, path string, cfg config) (trimmed float64, err error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve downloaded file path: %w", err)
+	}
+	if _, err := os.Stat(absPath); err != nil {
+		return 0, fmt.Errorf("downloaded file not found for effects: %w", err)
+	}
+
+	out, err := runner.Command(ctx, "ffmpeg", silenceAnalysisArgs(absPath, cfg)...).CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("ffmpeg silence analysis failed: %s", ffmpegErrorMessage(out, err))
+	}
+	spans, duration := parseSilenceDetect(string(out))
+	if duration <= 0 {
+		return 0, fmt.Errorf("could not determine the duration of %s", filepath.Base(absPath))
+	}
+	start, end := 0.0, duration
+	if cfg.TrimSilence {
+		start, end = audibleRange(spans, duration)
+	}
+	length := end - start
+	fadeIn, _ := parseTimestampSeconds(strings.TrimSpace(cfg.FadeIn))
+	fadeOut, _ := parseTimestampSeconds(strings.TrimSpace(cfg.FadeOut))
+	if length == duration && fadeIn == 0 && fadeOut == 0 {
+		return 0, nil
+	}
+
+	ext := filepath.Ext(absPath)
+	base := strings.TrimSuffix(filepath.Base(absPath), ext)
+	tmpFile, err := os.CreateTemp(filepath.Dir(absPath), base+".ytcli-effects-*"+ext)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temporary effects file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	tmpFile.Close()
+	defer os.Remove(tmpPath)
+
+	startArg := ""
+	if start > 0 {
+		startArg = formatSeconds(start)
+	}
+	args := effectsArgs(absPath, tmpPath, cfg, startArg, formatSeconds(length),
+		fadeFilters("afade", fadeIn, fadeOut, length), fadeFilters("fade", fadeIn, fadeOut, length))
+	if out, err := runner.Command(ctx, "ffmpeg", args...).CombinedOutput(); err != nil {
+		return 0, fmt.Errorf("ffmpeg effects pass failed: %s", ffmpegErrorMessage(out, err))
+	}
+	if err := os.Rename(tmpPath, absPath); err != nil {
+		return 0, fmt.Errorf("failed to finalize processed file: %w", err)
+	}
+	return duration - length, nil
+}
+
+// silenceAnalysisArgs returns the ffmpeg arguments of the pass that reads
+// the duration of path and, with --trim-silence, its silences.
+func silenceAnalysisArgs(path string, cfg config) []string {
+	detect := "anull"
+	if cfg.TrimSilence {
+		detect = fmt.Sprintf("silencedetect=noise=%sdB:d=%s",
+			strconv.FormatFloat(cfg.SilenceThreshold, 'f', -1, 64), strconv.FormatFloat(minSilence, 'f', -1, 64))
+	}
+	return []string{"-hide_banner", "-nostdin", "-i", path, "-map", "0:a:0", "-af", detect, "-f", "null", "-"}
+}
+
+// effectsArgs returns the ffmpeg arguments that write length seconds of path
+// from start (empty for the beginning) to out with the given fade filters.
+func effectsArgs(path, out string, cfg config, start, length string, audioFilters, videoFilters []string) []string {
+	args := []string{"-hide_banner", "-loglevel", "error", "-nostdin", "-y"}
+	if start != "" {
+		args = append(args, "-ss", start)
+	}
+	args = append(args, "-t", length, "-i", path, "-map", "0", "-map_metadata", "0", "-c", "copy")
+	if len(audioFilters) > 0 {
+		args = append(args, "-af", strings.Join(audioFilters, ","))
+	}
+	args = append(args, audioEncoderArgs(filepath.Ext(out))...)
+	if cfg.Mode == "full" {
+		// Cutting at arbitrary times needs re-encoded video to stay in sync.
+		if len(videoFilters) > 0 {
+			args = append(args, "-vf", strings.Join(videoFilters, ","))
+		}
+		args = append(args, videoEncoderArgs(cfg)...)
+	}
+	return append(args, out)
+}
+
+// applyEffectsStep runs applyEffects on the download and reports what
+// changed. A failure leaves the file untouched.
+func applyEffectsStep(ctx context.Context, job *postJob) error {
+	cfg := job.Cfg
+	trimmed, err := applyEffects(ctx, job.Path, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to apply silence trimming and fades (%v)", err)
+	}
+	if cfg.TrimSilence {
+		fmt.Fprintf(job.Stdout, "Trimmed silence: %ss\n", formatSeconds(trimmed))
+	}
+	if cfg.FadeIn != "" || cfg.FadeOut != "" {
+		fmt.Fprintf(job.Stdout, "Applied fades: in %s, out %s\n", orNone(cfg.FadeIn), orNone(cfg.FadeOut))
+	}
+	return nil
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const silenceDetectOutput = `Input #0, mp3, from 'Clip.mp3':
+  Duration: 00:01:00.50, start: 0.025057, bitrate: 245 kb/s
+  Stream #0:0: Audio: mp3, 44100 Hz, stereo, fltp, 245 kb/s
+[silencedetect @ 0x5591] silence_start: -0.025057
+[silencedetect @ 0x5591] silence_end: 1.82 | silence_duration: 1.845057
+[silencedetect @ 0x5591] silence_start: 30.1
+[silencedetect @ 0x5591] silence_end: 31.2 | silence_duration: 1.1
+[silencedetect @ 0x5591] silence_start: 57.4
+size=N/A time=00:01:00.50 bitrate=N/A speed= 412x
+`
+
+func TestParseSilenceDetect(t *testing.T) {
+	spans, duration := parseSilenceDetect(silenceDetectOutput)
+	if duration != 60.5 {
+		t.Fatalf("got duration %v", duration)
+	}
+	want := []silenceSpan{{-0.025057, 1.82}, {30.1, 31.2}, {57.4, -1}}
+	if len(spans) != len(want) {
+		t.Fatalf("got %+v, want %+v", spans, want)
+	}
+	for i := range want {
+		if spans[i] != want[i] {
+			t.Fatalf("span %d: got %+v, want %+v", i, spans[i], want[i])
+		}
+	}
+
+	start, end := audibleRange(spans, duration)
+	if start != 1.82 || end != 57.4 {
+		t.Fatalf("got range %v-%v", start, end)
+	}
+}
+
+func TestAudibleRange(t *testing.T) {
+	tests := []struct {
+		spans      []silenceSpan
+		start, end float64
+	}{
+		{nil, 0, 60},
+		{[]silenceSpan{{10, 12}}, 0, 60},
+		{[]silenceSpan{{0, 2.5}}, 2.5, 60},
+		{[]silenceSpan{{55, 60}}, 0, 55},
+		{[]silenceSpan{{0, 2}, {58, 59.98}}, 2, 58},
+	}
+	for _, tc := range tests {
+		if start, end := audibleRange(tc.spans, 60); start != tc.start || end != tc.end {
+			t.Fatalf("%+v: got %v-%v, want %v-%v", tc.spans, start, end, tc.start, tc.end)
+		}
+	}
+}
+
+func TestFadeFilters(t *testing.T) {
+	if got := strings.Join(fadeFilters("afade", 2, 3.5, 55.58), ","); got != "afade=t=in:st=0:d=2.000,afade=t=out:st=52.080:d=3.500" {
+		t.Fatalf("got %q", got)
+	}
+	if got := strings.Join(fadeFilters("fade", 0, 10, 4), ","); got != "fade=t=out:st=0.000:d=4.000" {
+		t.Fatalf("fade longer than the output: got %q", got)
+	}
+	if got := fadeFilters("afade", 0, 0, 60); len(got) != 0 {
+		t.Fatalf("expected no filters, got %v", got)
+	}
+}
+
+func TestVideoEncoderArgs(t *testing.T) {
+	tests := []struct {
+		cfg  config
+		want string
+	}{
+		{config{}, "-c:v libx264 -crf 18 -preset medium"},
+		{config{Container: "webm"}, "-c:v libvpx-vp9 -crf 31 -b:v 0"},
+		{config{Container: "mkv", VCodec: "av1"}, "-c:v libsvtav1 -crf 30"},
+	}
+	for _, tc := range tests {
+		if got := strings.Join(videoEncoderArgs(tc.cfg), " "); got != tc.want {
+			t.Fatalf("%+v: got %q, want %q", tc.cfg, got, tc.want)
+		}
+	}
+}
+
+func TestParseConfigEffects(t *testing.T) {
+	cfg, _, err := parseConfig([]string{"--mode", "audio", "--trim-silence", "--silence-threshold", "-40", "--fade-in", "2s", "--fade-out", "0:03.5", "https://youtu.be/example"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.TrimSilence || cfg.SilenceThreshold != -40 || !cfg.hasEffects() || archiveVariant(cfg) != "audio+effects" {
+		t.Fatalf("unexpected config: %+v (variant %q)", cfg, archiveVariant(cfg))
+	}
+
+	expectParseError(t, "require --mode audio or full", "--mode", "video", "--fade-in", "2", "https://youtu.be/example")
+	expectParseError(t, "requires --trim-silence", "--silence-threshold", "-40", "https://youtu.be/example")
+	expectParseError(t, "between -100 and 0", "--trim-silence", "--silence-threshold", "6", "https://youtu.be/example")
+	expectParseError(t, "invalid --fade-out duration", "--fade-out", "soon", "https://youtu.be/example")
+	expectParseError(t, "invalid --fade-in duration", "--fade-in", "0", "https://youtu.be/example")
+	expectParseError(t, "--split-chapters", "--mode", "audio", "--trim-silence", "--split-chapters", "https://youtu.be/example")
+}
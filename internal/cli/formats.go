@@ -0,0 +1,230 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// videoCodecs maps --vcodec values to the codec prefixes yt-dlp reports in
+// a format's vcodec field.
+var videoCodecs = map[string][]string{
+	"h264": {"avc", "h264"},
+	"vp9":  {"vp09", "vp9"},
+	"av1":  {"av01"},
+}
+
+var videoContainers = []string{"mp4", "mkv", "webm"}
+
+// webmVideoCodecs are the --vcodec values a webm container can hold.
+var webmVideoCodecs = []string{"vp9", "av1"}
+
+// container returns the --container value, defaulting to mp4.
+func (c config) container() string {
+	if c.Container == "" {
+		return "mp4"
+	}
+	return c.Container
+}
+
+// hasVideoConstraints reports whether any option narrows the video formats
+// yt-dlp may pick.
+func (c config) hasVideoConstraints() bool {
+	return c.MaxHeight > 0 || c.FPS > 0 || c.VCodec != "" || c.container() == "webm"
+}
+
+func validateVideoOptions(cfg config) error {
+	if cfg.MaxHeight < 0 {
+		return fmt.Errorf("--max-height must not be negative")
+	}
+	if cfg.FPS < 0 {
+		return fmt.Errorf("--fps must not be negative")
+	}
+	if _, ok := videoCodecs[cfg.VCodec]; cfg.VCodec != "" && !ok {
+		return fmt.Errorf("invalid video codec %q; expected h264, vp9, or av1", cfg.VCodec)
+	}
+	valid := false
+	for _, c := range videoContainers {
+		valid = valid || cfg.container() == c
+	}
+	if !valid {
+		return fmt.Errorf("invalid container %q; expected %s", cfg.Container, strings.Join(videoContainers, ", "))
+	}
+	if cfg.Mode == "audio" && (cfg.MaxHeight > 0 || cfg.FPS > 0 || cfg.VCodec != "" || cfg.container() != "mp4") {
+		return fmt.Errorf("--max-height, --fps, --vcodec and --container are only supported with --mode video or full")
+	}
+	if cfg.container() == "webm" && cfg.VCodec == "h264" {
+		return fmt.Errorf("--vcodec h264 cannot be stored in a webm container; use mp4 or mkv")
+	}
+	return nil
+}
+
+// videoFormatFilter renders the constraints as yt-dlp format filters. Formats
+// that do not report a height or frame rate are kept ("<=?").
+func videoFormatFilter(cfg config) string {
+	var b strings.Builder
+	if cfg.MaxHeight > 0 {
+		fmt.Fprintf(&b, "[height<=?%d]", cfg.MaxHeight)
+	}
+	if cfg.FPS > 0 {
+		fmt.Fprintf(&b, "[fps<=?%d]", cfg.FPS)
+	}
+	if prefixes, ok := videoCodecs[cfg.VCodec]; ok {
+		fmt.Fprintf(&b, "[vcodec~='^(%s)']", strings.Join(prefixes, "|"))
+	}
+	return b.String()
+}
+
+// webmCodecFilter matches the video streams that can be remuxed into webm
+// whatever container yt-dlp offers them in.
+func webmCodecFilter() string {
+	var prefixes []string
+	for _, codec := range webmVideoCodecs {
+		prefixes = append(prefixes, videoCodecs[codec]...)
+	}
+	return "[vcodec~='^(" + strings.Join(prefixes, "|") + ")']"
+}
+
+// webmCodec reports whether a format's vcodec can be stored in webm.
+func webmCodec(vcodec string) bool {
+	for _, codec := range webmVideoCodecs {
+		for _, prefix := range videoCodecs[codec] {
+			if strings.HasPrefix(vcodec, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// videoFormatArgs returns the format selector and container options for the
+// video and full modes. Each selector prefers streams that already fit the
+// container and falls back to any stream satisfying the constraints.
+func videoFormatArgs(cfg config) []string {
+	filter := videoFormatFilter(cfg)
+	container := cfg.container()
+
+	if cfg.Mode == "video" {
+		switch container {
+		case "webm":
+			// vp9 and av1 streams are also offered in mp4; remuxing them
+			// keeps the video as is.
+			return []string{"-f", "bv*" + filter + "[ext=webm]/bv*" + filter + webmCodecFilter(), "--remux-video", "webm"}
+		case "mkv":
+			return []string{"-f", "bv*" + filter, "--remux-video", "mkv"}
+		}
+		args := []string{"-f", "bv*" + filter + "[ext=mp4]/bv*" + filter}
+		if cfg.VCodec == "" || cfg.VCodec == "h264" {
+			return append(args, "--recode-video", "mp4")
+		}
+		// Recoding would turn vp9/av1 back into h264; mp4 holds both as is.
+		return append(args, "--remux-video", "mp4")
+	}
+
+	if container == "webm" {
+		selector := "bv*" + filter + "[ext=webm]+ba[ext=webm]/b" + filter + "[ext=webm]"
+		return []string{"-f", selector, "--merge-output-format", "webm"}
+	}
+	return []string{"-f", "bv*" + filter + "+ba/b" + filter, "--merge-output-format", container}
+}
+
+// videoVariant summarizes the non-default video options for the download
+// archive, e.g. "720p,30fps,vp9,mkv".
+func videoVariant(cfg config) string {
+	var parts []string
+	if cfg.MaxHeight > 0 {
+		parts = append(parts, strconv.Itoa(cfg.MaxHeight)+"p")
+	}
+	if cfg.FPS > 0 {
+		parts = append(parts, strconv.Itoa(cfg.FPS)+"fps")
+	}
+	if cfg.VCodec != "" {
+		parts = append(parts, cfg.VCodec)
+	}
+	if cfg.container() != "mp4" {
+		parts = append(parts, cfg.container())
+	}
+	return strings.Join(parts, ",")
+}
+
+func formatMatches(f videoFormat, cfg config) bool {
+	if f.VCodec == "" || f.VCodec == "none" {
+		return false
+	}
+	if cfg.MaxHeight > 0 && f.Height > cfg.MaxHeight {
+		return false
+	}
+	if cfg.FPS > 0 && f.FPS > float64(cfg.FPS) {
+		return false
+	}
+	if prefixes, ok := videoCodecs[cfg.VCodec]; ok {
+		matched := false
+		for _, prefix := range prefixes {
+			matched = matched || strings.HasPrefix(f.VCodec, prefix)
+		}
+		if !matched {
+			return false
+		}
+	}
+	if cfg.container() != "webm" || f.Ext == "webm" {
+		return true
+	}
+	return cfg.Mode == "video" && webmCodec(f.VCodec)
+}
+
+// checkVideoFormats fails with a list of what is available when none of
+// formats satisfies the video options of cfg.
+func checkVideoFormats(formats []videoFormat, cfg config) error {
+	seen := map[string]bool{}
+	var available []videoFormat
+	for _, f := range formats {
+		if formatMatches(f, cfg) {
+			return nil
+		}
+		if f.VCodec == "" || f.VCodec == "none" {
+			continue
+		}
+		key := describeVideoFormat(f)
+		if !seen[key] {
+			seen[key] = true
+			available = append(available, f)
+		}
+	}
+
+	sort.SliceStable(available, func(i, j int) bool { return available[i].Height > available[j].Height })
+	descriptions := make([]string, 0, len(available))
+	for _, f := range available {
+		descriptions = append(descriptions, describeVideoFormat(f))
+	}
+	if len(descriptions) == 0 {
+		return fmt.Errorf("no video format satisfies %s; the video has no video streams", videoConstraintFlags(cfg))
+	}
+	return fmt.Errorf("no video format satisfies %s; available: %s", videoConstraintFlags(cfg), strings.Join(descriptions, ", "))
+}
+
+func describeVideoFormat(f videoFormat) string {
+	codec, _, _ := strings.Cut(f.VCodec, ".")
+	desc := strconv.Itoa(f.Height) + "p"
+	if f.FPS > 0 {
+		desc += strconv.FormatFloat(f.FPS, 'f', -1, 64)
+	}
+	return desc + " " + codec + " " + f.Ext
+}
+
+func videoConstraintFlags(cfg config) string {
+	var flags []string
+	if cfg.MaxHeight > 0 {
+		flags = append(flags, "--max-height "+strconv.Itoa(cfg.MaxHeight))
+	}
+	if cfg.FPS > 0 {
+		flags = append(flags, "--fps "+strconv.Itoa(cfg.FPS))
+	}
+	if cfg.VCodec != "" {
+		flags = append(flags, "--vcodec "+cfg.VCodec)
+	}
+	if cfg.container() != "mp4" {
+		flags = append(flags, "--container "+cfg.container())
+	}
+	return strings.Join(flags, " ")
+}
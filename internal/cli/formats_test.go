@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestVideoFormatArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config
+		want string
+	}{
+		{"video default", config{Mode: "video"}, "-f bv*[ext=mp4]/bv* --recode-video mp4"},
+		{"full default", config{Mode: "full"}, "-f bv*+ba/b --merge-output-format mp4"},
+		{
+			"full constrained",
+			config{Mode: "full", MaxHeight: 720, FPS: 30, VCodec: "vp9", Container: "mkv"},
+			"-f bv*[height<=?720][fps<=?30][vcodec~='^(vp09|vp9)']+ba/b[height<=?720][fps<=?30][vcodec~='^(vp09|vp9)'] --merge-output-format mkv",
+		},
+		{"full webm", config{Mode: "full", Container: "webm"}, "-f bv*[ext=webm]+ba[ext=webm]/b[ext=webm] --merge-output-format webm"},
+		{"video av1 mp4", config{Mode: "video", VCodec: "av1"}, "-f bv*[vcodec~='^(av01)'][ext=mp4]/bv*[vcodec~='^(av01)'] --remux-video mp4"},
+		{"video h264 height", config{Mode: "video", MaxHeight: 480, VCodec: "h264"}, "-f bv*[height<=?480][vcodec~='^(avc|h264)'][ext=mp4]/bv*[height<=?480][vcodec~='^(avc|h264)'] --recode-video mp4"},
+		{"video mkv", config{Mode: "video", Container: "mkv"}, "-f bv* --remux-video mkv"},
+		{"video webm", config{Mode: "video", Container: "webm", MaxHeight: 720}, "-f bv*[height<=?720][ext=webm]/bv*[height<=?720][vcodec~='^(vp09|vp9|av01)'] --remux-video webm"},
+	}
+
+	for _, tc := range tests {
+		if got := strings.Join(videoFormatArgs(tc.cfg), " "); got != tc.want {
+			t.Fatalf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCheckVideoFormats(t *testing.T) {
+	formats := []videoFormat{
+		{ID: "251", Ext: "webm", ACodec: "opus", VCodec: "none"},
+		{ID: "137", Ext: "mp4", Height: 1080, FPS: 30, VCodec: "avc1.640028", ACodec: "none"},
+		{ID: "247", Ext: "webm", Height: 720, FPS: 30, VCodec: "vp9", ACodec: "none"},
+		{ID: "136", Ext: "mp4", Height: 720, FPS: 30, VCodec: "avc1.4d401f", ACodec: "none"},
+	}
+
+	if err := checkVideoFormats(formats, config{MaxHeight: 720, VCodec: "vp9"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := checkVideoFormats(formats, config{Container: "webm"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := checkVideoFormats(formats, config{MaxHeight: 480, VCodec: "av1"})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	want := "no video format satisfies --max-height 480 --vcodec av1; available: 1080p30 avc1 mp4, 720p30 vp9 webm, 720p30 avc1 mp4"
+	if err.Error() != want {
+		t.Fatalf("got %q, want %q", err.Error(), want)
+	}
+
+	// Only video mode can remux an mp4-packaged av1 stream into webm.
+	av1 := append(formats[:2:2], videoFormat{ID: "399", Ext: "mp4", Height: 1080, FPS: 30, VCodec: "av01.0.08M.08", ACodec: "none"})
+	if err := checkVideoFormats(av1, config{Mode: "video", Container: "webm"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := checkVideoFormats(av1, config{Mode: "full", Container: "webm"}); err == nil {
+		t.Fatal("expected error for full mode webm without webm streams")
+	}
+
+	if err := checkVideoFormats(formats[:1], config{FPS: 30}); err == nil || !strings.Contains(err.Error(), "no video streams") {
+		t.Fatalf("expected no video streams error, got %v", err)
+	}
+}
+
+func TestParseConfigVideoOptions(t *testing.T) {
+	cfg, _, err := parseConfig([]string{"--max-height", "720", "--vcodec", "vp9", "--container", "mkv", "https://youtu.be/example"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if archiveVariant(cfg) != "full:720p,vp9,mkv" {
+		t.Fatalf("got variant %q", archiveVariant(cfg))
+	}
+	if finalExtension(cfg, nil) != "mkv" {
+		t.Fatalf("got extension %q", finalExtension(cfg, nil))
+	}
+
+	expectParseError(t, "invalid video codec", "--vcodec", "hevc", "https://youtu.be/example")
+	expectParseError(t, "invalid container", "--container", "avi", "https://youtu.be/example")
+	expectParseError(t, "only supported with --mode", "--mode", "audio", "--max-height", "720", "https://youtu.be/example")
+	expectParseError(t, "cannot be stored in a webm", "--vcodec", "h264", "--container", "webm", "https://youtu.be/example")
+	expectParseError(t, "--fps must not be negative", "--fps", "-1", "https://youtu.be/example")
+}
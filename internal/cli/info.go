@@ -0,0 +1,425 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// ytDlpDefaultTemplate is the output template yt-dlp uses when ytcli does not
+// pass -o.
+const ytDlpDefaultTemplate = "%(title)s [%(id)s].%(ext)s"
+
+var reTemplateField = regexp.MustCompile(`%%|%\(([^)]*)\)([sd])`)
+
+type videoFormat struct {
+	ID         string  `json:"format_id"`
+	Ext        string  `json:"ext"`
+	Resolution string  `json:"resolution,omitempty"`
+	Height     int     `json:"height,omitempty"`
+	FPS        float64 `json:"fps,omitempty"`
+	VCodec     string  `json:"vcodec,omitempty"`
+	ACodec     string  `json:"acodec,omitempty"`
+	Filesize   int64   `json:"filesize,omitempty"`
+	FilesizeEx int64   `json:"filesize_approx,omitempty"`
+	Bitrate    float64 `json:"tbr,omitempty"`
+	Note       string  `json:"format_note,omitempty"`
+}
+
+// videoInfo is the subset of yt-dlp's info JSON that ytcli uses. Fields keeps
+// the full decoded object for output template expansion.
+type videoInfo struct {
+	ID        string         `json:"id"`
+	Extractor string         `json:"extractor_key"`
+	Title     string         `json:"title"`
+	Uploader  string         `json:"uploader"`
+	Channel   string         `json:"channel"`
+	Artist    string         `json:"artist"`
+	Track     string         `json:"track"`
+	Duration  float64        `json:"duration"`
+	Formats   []videoFormat  `json:"formats"`
+	Chapters  []videoChapter `json:"chapters"`
+	Fields    map[string]any `json:"-"`
+
+	Album       string   `json:"album"`
+	AlbumArtist string   `json:"album_artist"`
+	TrackNumber int      `json:"track_number"`
+	Genre       string   `json:"genre"`
+	Genres      []string `json:"genres"`
+	ReleaseDate string   `json:"release_date"`
+	ReleaseYear int      `json:"release_year"`
+	UploadDate  string   `json:"upload_date"`
+	WebpageURL  string   `json:"webpage_url"`
+}
+
+func parseVideoInfo(data []byte) (*videoInfo, error) {
+	var info videoInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse video info: %w", err)
+	}
+	if err := json.Unmarshal(data, &info.Fields); err != nil {
+		return nil, fmt.Errorf("failed to parse video info: %w", err)
+	}
+	return &info, nil
+}
+
+func fetchVideoInfo(ctx context.Context, ytDlpBinary, url string) (*videoInfo, error) {
+	cmd := runner.Command(
+		ctx,
+		ytDlpBinary,
+		"--dump-single-json",
+		"--no-warnings",
+		"--no-playlist",
+		url,
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch video info: %w", err)
+	}
+	return parseVideoInfo(out)
+}
+
+// uploaderName mirrors yt-dlp's %(uploader)s with a fallback to the channel.
+func (v *videoInfo) uploaderName() string {
+	if strings.TrimSpace(v.Uploader) != "" {
+		return v.Uploader
+	}
+	return v.Channel
+}
+
+// formatYtDlpDate turns yt-dlp's YYYYMMDD dates into YYYY-MM-DD.
+func formatYtDlpDate(date string) string {
+	if len(date) != 8 {
+		return date
+	}
+	return date[:4] + "-" + date[4:6] + "-" + date[6:]
+}
+
+// releaseDate prefers the release date, then the release year and finally
+// the upload date.
+func (v *videoInfo) releaseDate() string {
+	switch {
+	case v.ReleaseDate != "":
+		return formatYtDlpDate(v.ReleaseDate)
+	case v.ReleaseYear > 0:
+		return strconv.Itoa(v.ReleaseYear)
+	default:
+		return formatYtDlpDate(v.UploadDate)
+	}
+}
+
+func (v *videoInfo) genre() string {
+	if strings.TrimSpace(v.Genre) != "" || len(v.Genres) == 0 {
+		return strings.TrimSpace(v.Genre)
+	}
+	return strings.TrimSpace(v.Genres[0])
+}
+
+// tagMetadata resolves the audio tags for the video: artist and title from
+// %(artist,uploader)s and %(track,title)s, plus album, date, genre and the
+// source url where yt-dlp knows them.
+func (v *videoInfo) tagMetadata() (*trackMetadata, error) {
+	artist := v.Artist
+	if strings.TrimSpace(artist) == "" {
+		artist = v.uploaderName()
+	}
+	title := v.Track
+	if strings.TrimSpace(title) == "" {
+		title = v.Title
+	}
+
+	meta := trackMetadata{
+		Artist:  cleanArtist(artist),
+		Title:   cleanTitle(title),
+		Album:   strings.TrimSpace(v.Album),
+		Track:   v.TrackNumber,
+		Date:    v.releaseDate(),
+		Genre:   v.genre(),
+		Comment: v.WebpageURL,
+	}
+	if strings.TrimSpace(v.AlbumArtist) != "" {
+		meta.AlbumArtist = cleanArtist(v.AlbumArtist)
+	}
+	if meta.Title == "" {
+		return nil, fmt.Errorf("missing track title metadata")
+	}
+	return &meta, nil
+}
+
+func templateFieldValue(fields map[string]any, name string) (string, bool) {
+	value, ok := fields[name]
+	if !ok || value == nil {
+		return "", false
+	}
+
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = v
+	case float64:
+		if v == math.Trunc(v) {
+			text = strconv.FormatInt(int64(v), 10)
+		} else {
+			text = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	case bool:
+		text = strconv.FormatBool(v)
+	default:
+		return "", false
+	}
+	if text == "" {
+		return "", false
+	}
+	return text, true
+}
+
+// expandOutputTemplate resolves the %(a,b)s fields of a yt-dlp output
+// template against an info JSON object the way yt-dlp does for plain field
+// references, including "NA" for missing values and "/" replacement.
+func expandOutputTemplate(template string, fields map[string]any) string {
+	return reTemplateField.ReplaceAllStringFunc(template, func(match string) string {
+		if match == "%%" {
+			return "%"
+		}
+		m := reTemplateField.FindStringSubmatch(match)
+		for _, name := range strings.Split(m[1], ",") {
+			if text, ok := templateFieldValue(fields, strings.TrimSpace(name)); ok {
+				return strings.ReplaceAll(text, "/", "⧸")
+			}
+		}
+		return "NA"
+	})
+}
+
+// finalExtension is the extension of the file ytcli leaves behind for cfg.
+// With --audio-format best it depends on the source stream in formats.
+func finalExtension(cfg config, formats []videoFormat) string {
+	if cfg.Mode != "audio" {
+		return cfg.container()
+	}
+	if cfg.audioFormat() == "best" {
+		return bestAudioExtension(formats)
+	}
+	return cfg.audioFormat()
+}
+
+func formatDuration(seconds float64) string {
+	total := int(math.Round(seconds))
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, total/60%60, total%60)
+}
+
+type infoReport struct {
+	URL            string         `json:"url"`
+	Mode           string         `json:"mode"`
+	ID             string         `json:"id"`
+	Extractor      string         `json:"extractor"`
+	RawTitle       string         `json:"raw_title"`
+	RawUploader    string         `json:"raw_uploader"`
+	RawArtist      string         `json:"raw_artist,omitempty"`
+	RawTrack       string         `json:"raw_track,omitempty"`
+	Parsed         trackMetadata  `json:"parsed"`
+	Tags           *trackMetadata `json:"tags,omitempty"`
+	OutputTemplate string         `json:"output_template"`
+	OutputPath     string         `json:"output_path"`
+	Duration       float64        `json:"duration_seconds"`
+	Formats        []videoFormat  `json:"formats"`
+}
+
+// buildInfoReport resolves everything `get` would decide for cfg from an
+// already fetched info object.
+func buildInfoReport(cfg config, info *videoInfo) (*infoReport, error) {
+	report := &infoReport{
+		URL:         cfg.URL,
+		Mode:        cfg.Mode,
+		ID:          info.ID,
+		Extractor:   info.Extractor,
+		RawTitle:    info.Title,
+		RawUploader: info.uploaderName(),
+		RawArtist:   info.Artist,
+		RawTrack:    info.Track,
+		Parsed:      parseTrackMetadata(info.Title, info.uploaderName()),
+		Duration:    info.Duration,
+		Formats:     info.Formats,
+	}
+
+	var meta *trackMetadata
+	if cfg.Mode == "audio" {
+		meta, _ = info.tagMetadata()
+		if updatedMeta, applied := applyManualMetadata(meta, cfg.Artist, cfg.Song); applied {
+			meta = updatedMeta
+		}
+		if meta != nil {
+			if cfg.ClipLabel != "" {
+				clipMeta := *meta
+				clipMeta.Title += clipSuffix(cfg.ClipLabel)
+				meta = &clipMeta
+			}
+			applyTagOverrides(meta, cfg.Album, cfg.Year, cfg.Genre)
+		}
+		report.Tags = meta
+	}
+
+	template, err := outputTemplate(cfg.Output, cfg, meta)
+	if err != nil {
+		return nil, err
+	}
+	if template == "" {
+		template = ytDlpDefaultTemplate
+	}
+	report.OutputTemplate = template
+
+	report.OutputPath = predictOutputPath(cfg, info, template)
+
+	return report, nil
+}
+
+// predictOutputPath names the file yt-dlp leaves behind for cfg when it
+// expands template for info. An empty template is yt-dlp's default.
+func predictOutputPath(cfg config, info *videoInfo, template string) string {
+	if template == "" {
+		template = ytDlpDefaultTemplate
+	}
+	fields := make(map[string]any, len(info.Fields)+1)
+	for k, v := range info.Fields {
+		fields[k] = v
+	}
+	fields["ext"] = finalExtension(cfg, info.Formats)
+	if cfg.ClipLabel != "" {
+		// Mirrors the --replace-in-metadata that gives a clip's title and
+		// track the clip suffix.
+		for _, name := range []string{"title", "track"} {
+			if text, ok := fields[name].(string); ok {
+				fields[name] = text + clipSuffix(cfg.ClipLabel)
+			}
+		}
+	}
+	return expandOutputTemplate(template, fields)
+}
+
+func formatSize(f videoFormat) string {
+	size := f.Filesize
+	prefix := ""
+	if size == 0 {
+		size = f.FilesizeEx
+		prefix = "~"
+	}
+	if size == 0 {
+		return ""
+	}
+	return prefix + formatBytes(size)
+}
+
+func writeInfoReport(w io.Writer, report *infoReport) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "URL:\t%s\n", report.URL)
+	fmt.Fprintf(tw, "ID:\t%s %s\n", report.Extractor, report.ID)
+	fmt.Fprintf(tw, "Mode:\t%s\n", report.Mode)
+	fmt.Fprintf(tw, "Duration:\t%s\n", formatDuration(report.Duration))
+	fmt.Fprintf(tw, "Title:\t%s\n", report.RawTitle)
+	fmt.Fprintf(tw, "Uploader:\t%s\n", report.RawUploader)
+	if report.RawArtist != "" {
+		fmt.Fprintf(tw, "Artist:\t%s\n", report.RawArtist)
+	}
+	if report.RawTrack != "" {
+		fmt.Fprintf(tw, "Track:\t%s\n", report.RawTrack)
+	}
+	fmt.Fprintf(tw, "Parsed title:\t%s - %s\n", report.Parsed.Artist, report.Parsed.Title)
+	if report.Tags != nil {
+		fmt.Fprintf(tw, "Audio tags:\t%s - %s\n", report.Tags.Artist, report.Tags.Title)
+		if extra := formatExtraTags(*report.Tags); extra != "" {
+			fmt.Fprintf(tw, "Extra tags:\t%s\n", extra)
+		}
+	} else if report.Mode == "audio" {
+		fmt.Fprintf(tw, "Audio tags:\tunresolved, inferred from the file name after download\n")
+	}
+	fmt.Fprintf(tw, "Output template:\t%s\n", report.OutputTemplate)
+	fmt.Fprintf(tw, "Output path:\t%s\n", report.OutputPath)
+	tw.Flush()
+
+	if len(report.Formats) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nFormats:\n")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tEXT\tRESOLUTION\tFPS\tVCODEC\tACODEC\tSIZE\tNOTE")
+	for _, f := range report.Formats {
+		fps := ""
+		if f.FPS > 0 {
+			fps = strconv.FormatFloat(f.FPS, 'f', -1, 64)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", f.ID, f.Ext, f.Resolution, fps, f.VCodec, f.ACodec, formatSize(f), f.Note)
+	}
+	tw.Flush()
+}
+
+func runInfoCommand(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	var cfg config
+	var jsonOutput bool
+	fs := newCommandFlagSet(
+		"info",
+		"ytcli info [download flags] [--json] <url>",
+		"Show the metadata ytcli resolves for a url, where the download would be\nwritten, its duration and available formats, without downloading anything.\nTakes the same download flags, config file and profiles as get.",
+		stderr,
+	)
+	registerDownloadFlags(fs, &cfg)
+	fs.BoolVar(&jsonOutput, "json", false, "print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return usageError(fs, stderr, err)
+	}
+	if err := applyUserConfig(fs, cfg.ConfigPath, cfg.Profile); err != nil {
+		return usageError(fs, stderr, err)
+	}
+	if fs.NArg() != 1 {
+		return usageError(fs, stderr, fmt.Errorf("missing required url argument"))
+	}
+	cfg.URL = fs.Arg(0)
+
+	switch cfg.Mode {
+	case "audio", "video", "full":
+	default:
+		return usageError(fs, stderr, fmt.Errorf("invalid mode %q; expected audio, video, or full", cfg.Mode))
+	}
+	if err := resolveClipOptions(&cfg); err != nil {
+		return usageError(fs, stderr, err)
+	}
+	if len(cfg.Clips) > 1 {
+		return usageError(fs, stderr, fmt.Errorf("info reports a single download; give at most one --clip"))
+	}
+	if err := validateOptions(cfg); err != nil {
+		return usageError(fs, stderr, err)
+	}
+
+	ytDlpBinary, err := resolveYtDlpBinary()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	info, err := fetchVideoInfo(ctx, ytDlpBinary, cfg.URL)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", interruptedError(ctx, err))
+		return failureStatus(ctx)
+	}
+	report, err := buildInfoReport(cfg, info)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	writeInfoReport(stdout, report)
+	return 0
+}
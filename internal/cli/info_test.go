@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const sampleInfoJSON = `{
+	"id": "abc123",
+	"extractor_key": "Youtube",
+	"title": "Daft Punk - One More Time (Official Video)",
+	"uploader": "Daft Punk",
+	"track": "One More Time",
+	"duration": 320,
+	"ext": "webm",
+	"formats": [
+		{"format_id": "251", "ext": "webm", "resolution": "audio only", "acodec": "opus", "vcodec": "none", "filesize": 5242880},
+		{"format_id": "137", "ext": "mp4", "resolution": "1920x1080", "height": 1080, "fps": 30, "vcodec": "avc1.640028", "acodec": "none"}
+	]
+}`
+
+func TestExpandOutputTemplate(t *testing.T) {
+	fields := map[string]any{
+		"title":    "AC/DC Live",
+		"uploader": "Uploader",
+		"id":       "abc",
+		"ext":      "mp4",
+		"duration": float64(95),
+	}
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{template: "%(title)s [%(id)s].%(ext)s", want: "AC⧸DC Live [abc].mp4"},
+		{template: "%(artist,uploader)s - %(track,title)s.%(ext)s", want: "Uploader - AC⧸DC Live.mp4"},
+		{template: "%(album)s/%(duration)ds 100%%", want: "NA/95s 100%"},
+	}
+
+	for _, tc := range tests {
+		if got := expandOutputTemplate(tc.template, fields); got != tc.want {
+			t.Fatalf("%s: got %q, want %q", tc.template, got, tc.want)
+		}
+	}
+}
+
+func TestBuildInfoReportAudio(t *testing.T) {
+	info, err := parseVideoInfo([]byte(sampleInfoJSON))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	report, err := buildInfoReport(config{Mode: "audio", URL: "https://youtu.be/abc123"}, info)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Parsed.Artist != "Daft Punk" || report.Parsed.Title != "One More Time" {
+		t.Fatalf("unexpected parsed metadata: %+v", report.Parsed)
+	}
+	if report.Tags == nil || report.Tags.Title != "One More Time" {
+		t.Fatalf("unexpected tags: %+v", report.Tags)
+	}
+	if report.OutputPath != "Daft Punk - One More Time.mp3" {
+		t.Fatalf("output path got %q", report.OutputPath)
+	}
+	if len(report.Formats) != 2 {
+		t.Fatalf("got %d formats, want 2", len(report.Formats))
+	}
+}
+
+func TestBuildInfoReportFullUsesYtDlpDefaultTemplate(t *testing.T) {
+	info, err := parseVideoInfo([]byte(sampleInfoJSON))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	report, err := buildInfoReport(config{Mode: "full"}, info)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Tags != nil {
+		t.Fatal("did not expect audio tags outside audio mode")
+	}
+	want := "Daft Punk - One More Time (Official Video) [abc123].mp4"
+	if report.OutputPath != want {
+		t.Fatalf("output path got %q, want %q", report.OutputPath, want)
+	}
+}
+
+func TestWriteInfoReport(t *testing.T) {
+	info, err := parseVideoInfo([]byte(sampleInfoJSON))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	report, err := buildInfoReport(config{Mode: "audio", URL: "https://youtu.be/abc123"}, info)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	writeInfoReport(&buf, report)
+	out := buf.String()
+	for _, want := range []string{"00:05:20", "Audio tags:", "Daft Punk - One More Time.mp3", "avc1.640028", "5.0MiB"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(data), `"tags":{"artist":"Daft Punk","title":"One More Time"}`) {
+		t.Fatalf("unexpected json: %s", data)
+	}
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// exitInterrupted is the exit status after SIGINT or SIGTERM, following the
+// shell convention of 128 + SIGINT.
+const exitInterrupted = 130
+
+var errInterrupted = errors.New("interrupted")
+
+// signalContext returns a context that is cancelled on the first SIGINT or
+// SIGTERM. Cancelling stops the running yt-dlp or ffmpeg process so ytcli
+// can clean up; a second signal terminates ytcli right away.
+func signalContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
+
+// interruptedError reports err as an interrupt once ctx is cancelled: any
+// tool failing after that was stopped by ytcli.
+func interruptedError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil || errors.Is(err, errAlreadyDownloaded) {
+		return err
+	}
+	return withKind(errKindInterrupted, errInterrupted)
+}
+
+// downloadArtifacts collects the files a yt-dlp run writes on the way to
+// the final file: every format stream it downloads (merged and removed once
+// yt-dlp finishes) and the thumbnail ytcli asked for with --write-thumbnail.
+type downloadArtifacts struct {
+	downloads map[string]bool
+	thumbnail string
+}
+
+func newDownloadArtifacts() *downloadArtifacts {
+	return &downloadArtifacts{downloads: map[string]bool{}}
+}
+
+// track records what a line of yt-dlp output reveals about the files it
+// writes. Only streams that are actually being downloaded are tracked, so a
+// file yt-dlp found already downloaded is never removed.
+func (a *downloadArtifacts) track(line string) {
+	if ev, ok := parseProgressLine(line); ok {
+		if ev.Phase == phaseDownload && ev.Status == "downloading" && ev.Filename != "" {
+			a.downloads[ev.Filename] = true
+		}
+		return
+	}
+	if path, ok := parsePrintedPath(line, thumbnailPrefix); ok {
+		a.thumbnail = path
+	}
+}
+
+// remove deletes the tracked streams together with the .part, fragment and
+// .ytdl files yt-dlp keeps to resume them, and the thumbnail.
+func (a *downloadArtifacts) remove() {
+	for name := range a.downloads {
+		os.Remove(name)
+		os.Remove(name + ".ytdl")
+		entries, err := os.ReadDir(filepath.Dir(name))
+		if err != nil {
+			continue
+		}
+		prefix := filepath.Base(name) + ".part"
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Name(), prefix) {
+				os.Remove(filepath.Join(filepath.Dir(name), entry.Name()))
+			}
+		}
+	}
+	a.removeThumbnail()
+}
+
+// removeThumbnail deletes the thumbnail in both its original and converted
+// form.
+func (a *downloadArtifacts) removeThumbnail() {
+	if a.thumbnail != "" {
+		os.Remove(a.thumbnail)
+		os.Remove(thumbnailPath(a.thumbnail))
+	}
+}
+
+// failureStatus is the exit status for a command that failed, which is
+// exitInterrupted when the failure was caused by an interrupt.
+func failureStatus(ctx context.Context) int {
+	if ctx.Err() != nil {
+		return exitInterrupted
+	}
+	return 1
+}
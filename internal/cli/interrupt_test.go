@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// cancelOnWrite cancels a run once it prints a line containing match.
+type cancelOnWrite struct {
+	bytes.Buffer
+	match  string
+	cancel context.CancelFunc
+}
+
+func (w *cancelOnWrite) Write(p []byte) (int, error) {
+	if strings.Contains(string(p), w.match) {
+		w.cancel()
+	}
+	return w.Buffer.Write(p)
+}
+
+// downloadProgressLine is a yt-dlp progress update for file, as printed
+// through the templates from progressArgs.
+func downloadProgressLine(status, downloaded, total, file string) string {
+	return progressPrefix + "download\t" + status + "\t" + downloaded + "\t" + total + "\tNA\t100\t5\t" + file
+}
+
+func TestRunInterruptRemovesDownloadArtifacts(t *testing.T) {
+	f := useFakeRunner(t)
+	dir := t.TempDir()
+	video := filepath.Join(dir, "Daft Punk - One More Time.f137.mp4")
+	audio := filepath.Join(dir, "Daft Punk - One More Time.f251.webm")
+	thumbnail := filepath.Join(dir, "Daft Punk - One More Time.webp")
+	earlier := filepath.Join(dir, "Daft Punk - Around the World.mp3")
+	for _, path := range []string{video, audio + ".part", audio + ".part-Frag3", audio + ".ytdl", thumbnail, thumbnailPath(thumbnail), earlier} {
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var output []string
+	for _, line := range []string{
+		thumbnailPrefix + thumbnail,
+		downloadProgressLine("downloading", "512", "NA", video),
+		downloadProgressLine("finished", "1024", "1024", video),
+		downloadProgressLine("downloading", "512", "1024", audio),
+	} {
+		output = append(output, "printf '%s\\n' "+shellQuote([]string{line}))
+	}
+	// exec keeps the fake's pid, so the SIGTERM from the cancelled run
+	// reaches sleep.
+	f.tool(t, "yt-dlp", `case " $* " in
+*" --dump-single-json "*)
+	cat <<'EOF'
+`+testVideoInfo+`
+EOF
+	;;
+*)
+	`+strings.Join(output, "\n\t")+`
+	exec sleep 30
+	;;
+esac
+`)
+
+	cfg, _, err := parseConfig([]string{"--no-archive", "--mode", "audio", testVideoURL}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected config error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stdout := &cancelOnWrite{match: " 50.0% of ", cancel: cancel}
+
+	started := time.Now()
+	result, err := run(ctx, cfg, stdout, io.Discard)
+	if elapsed := time.Since(started); elapsed >= terminateGrace {
+		t.Fatalf("yt-dlp was not terminated on cancel, run took %v", elapsed)
+	}
+	if !errors.Is(err, errInterrupted) || kindOf(err) != errKindInterrupted {
+		t.Fatalf("expected interrupted error, got %v", err)
+	}
+	if result.Status != statusFailed || result.ErrorKind != errKindInterrupted {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if strings.Contains(stdout.String(), thumbnailPrefix) {
+		t.Fatalf("thumbnail line was printed: %q", stdout.String())
+	}
+
+	leftovers, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(leftovers) != 1 || leftovers[0] != earlier {
+		t.Fatalf("expected only the earlier download to remain, got %v", leftovers)
+	}
+}
+
+func TestRunReadFailureRemovesDownloadArtifacts(t *testing.T) {
+	f := useFakeRunner(t)
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "Daft Punk - One More Time.f251.webm")
+	if err := os.WriteFile(audio+".part", nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A line longer than the scanner buffer makes reading the output fail
+	// while yt-dlp keeps running.
+	f.tool(t, "yt-dlp", `case " $* " in
+*" --dump-single-json "*)
+	cat <<'EOF'
+`+testVideoInfo+`
+EOF
+	;;
+*)
+	printf '%s\n' `+shellQuote([]string{downloadProgressLine("downloading", "512", "1024", audio)})+`
+	head -c 2000000 /dev/zero | tr '\0' x
+	echo
+	exec sleep 30
+	;;
+esac
+`)
+
+	started := time.Now()
+	result, _, _, err := runGet(t, "--mode", "audio", "--no-cover")
+	if elapsed := time.Since(started); elapsed >= terminateGrace {
+		t.Fatalf("yt-dlp was not stopped after the read failure, run took %v", elapsed)
+	}
+	if err == nil || kindOf(err) != errKindDownload || !strings.HasPrefix(err.Error(), "failed to read yt-dlp output:") {
+		t.Fatalf("expected read error, got %v", err)
+	}
+	if result.Status != statusFailed {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(dir, "*")); len(leftovers) != 0 {
+		t.Fatalf("partial download left behind: %v", leftovers)
+	}
+}
+
+func TestRunInterruptAfterDownloadFinished(t *testing.T) {
+	f := useFakeRunner(t)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "Daft Punk - One More Time [u9oxz7AQg5c].mp4")
+	thumbnail := filepath.Join(dir, "Daft Punk - One More Time [u9oxz7AQg5c].webp")
+	for _, path := range []string{file, thumbnail} {
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// yt-dlp ignores the SIGTERM and exits 0: the download completed
+	// before the interrupt reached it.
+	f.tool(t, "yt-dlp", `trap '' TERM
+case " $* " in
+*" --dump-single-json "*)
+	cat <<'EOF'
+`+testVideoInfo+`
+EOF
+	;;
+*)
+	printf '%s\n' `+shellQuote([]string{thumbnailPrefix + thumbnail})+`
+	printf '%s\n' `+shellQuote([]string{downloadProgressLine("downloading", "512", "1024", file)})+`
+	sleep 1
+	;;
+esac
+`)
+
+	cfg, _, err := parseConfig([]string{"--no-archive", "--mode", "audio", testVideoURL}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected config error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stdout := &cancelOnWrite{match: " 50.0% of ", cancel: cancel}
+
+	result, err := run(ctx, cfg, stdout, io.Discard)
+	if !errors.Is(err, errInterrupted) || kindOf(err) != errKindInterrupted {
+		t.Fatalf("expected interrupted error, got %v", err)
+	}
+	if result.Status != statusFailed || result.ErrorKind != errKindInterrupted {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if calls := f.callsTo("ffmpeg"); len(calls) != 0 {
+		t.Fatalf("post-processing should not run after an interrupt: %v", calls)
+	}
+
+	leftovers, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(leftovers) != 1 || leftovers[0] != file {
+		t.Fatalf("expected only the finished download to remain, got %v", leftovers)
+	}
+}
+
+func TestDownloadArtifactsRemove(t *testing.T) {
+	dir := t.TempDir()
+	download := filepath.Join(dir, "Boiler Room Set [x].f140.m4a")
+	existing := filepath.Join(dir, "Boiler Room Set [x].f248.webm")
+	keep := []string{existing, filepath.Join(dir, "Other.m4a.part")}
+	remove := []string{download, download + ".part", download + ".part-Frag12.part", download + ".ytdl"}
+	for _, path := range append(keep, remove...) {
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	artifacts := newDownloadArtifacts()
+	artifacts.track(downloadProgressLine("downloading", "0", "NA", download))
+	// yt-dlp reports a format it already has as finished without
+	// downloading it.
+	artifacts.track(downloadProgressLine("finished", "2048", "2048", existing))
+	artifacts.track(thumbnailPrefix + "NA")
+	artifacts.track("[download] Destination: " + existing)
+	artifacts.remove()
+
+	for _, path := range keep {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("%s should be kept: %v", filepath.Base(path), err)
+		}
+	}
+	for _, path := range remove {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("%s should be removed: %v", filepath.Base(path), err)
+		}
+	}
+}
+
+func TestInterruptedError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	failure := withKind(errKindDownload, errors.New("download failed: signal: terminated"))
+	if err := interruptedError(ctx, failure); err != failure {
+		t.Fatalf("error before cancel should be kept, got %v", err)
+	}
+
+	cancel()
+	if err := interruptedError(ctx, failure); !errors.Is(err, errInterrupted) || kindOf(err) != errKindInterrupted {
+		t.Fatalf("expected interrupted error, got %v", err)
+	}
+	if err := interruptedError(ctx, errAlreadyDownloaded); err != errAlreadyDownloaded {
+		t.Fatalf("skips should be kept, got %v", err)
+	}
+	if err := interruptedError(ctx, nil); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if failureStatus(ctx) != exitInterrupted || failureStatus(context.Background()) != 1 {
+		t.Fatalf("unexpected exit status")
+	}
+}
+
+func TestRunBatchAfterInterrupt(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	items := []batchItem{{Line: 1, URL: "https://youtu.be/a"}, {Line: 2, URL: "https://youtu.be/b"}}
+	results := runBatch(ctx, config{Mode: "audio", Jobs: 2}, items, io.Discard, io.Discard)
+	for _, r := range results {
+		if r.Status != statusSkipped || r.Detail != "interrupted" || r.Result.Status != statusSkipped {
+			t.Fatalf("unexpected result after interrupt: %+v", r)
+		}
+	}
+}
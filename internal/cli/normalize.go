@@ -0,0 +1,229 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultTargetLUFS = -14.0
+	loudnormTruePeak  = -1.0
+	loudnormRange     = 11.0
+)
+
+var reSampleRate = regexp.MustCompile(`Audio: .*?, (\d+) Hz`)
+
+// loudnessStats is the measurement loudnorm prints with print_format=json.
+// ffmpeg reports every value as a string.
+type loudnessStats struct {
+	InputI       string `json:"input_i"`
+	InputTP      string `json:"input_tp"`
+	InputLRA     string `json:"input_lra"`
+	InputThresh  string `json:"input_thresh"`
+	TargetOffset string `json:"target_offset"`
+}
+
+func validateNormalizeOptions(cfg config) error {
+	if !cfg.Normalize {
+		if cfg.TargetLUFS != defaultTargetLUFS {
+			return fmt.Errorf("--target-lufs requires --normalize")
+		}
+		return nil
+	}
+	if cfg.Mode == "video" {
+		return fmt.Errorf("--normalize requires --mode audio or full; video mode has no audio")
+	}
+	if cfg.TargetLUFS < -70 || cfg.TargetLUFS > -5 {
+		return fmt.Errorf("--target-lufs must be between -70 and -5")
+	}
+	return nil
+}
+
+// loudnormFilter renders the loudnorm filter for target. With measured
+// stats from a first pass it applies a linear gain instead of the dynamic
+// single-pass mode.
+func loudnormFilter(target float64, measured *loudnessStats) string {
+	filter := fmt.Sprintf("loudnorm=I=%s:TP=%s:LRA=%s",
+		strconv.FormatFloat(target, 'f', -1, 64),
+		strconv.FormatFloat(loudnormTruePeak, 'f', 1, 64),
+		strconv.FormatFloat(loudnormRange, 'f', -1, 64),
+	)
+	if measured == nil {
+		return filter + ":print_format=json"
+	}
+	return filter + fmt.Sprintf(":measured_I=%s:measured_TP=%s:measured_LRA=%s:measured_thresh=%s:offset=%s:linear=true:print_format=summary",
+		measured.InputI, measured.InputTP, measured.InputLRA, measured.InputThresh, measured.TargetOffset)
+}
+
+// parseLoudnessStats reads the JSON block loudnorm appends to ffmpeg's
+// stderr at the end of the first pass.
+func parseLoudnessStats(output string) (loudnessStats, error) {
+	start := strings.LastIndex(output, "{")
+	end := strings.LastIndex(output, "}")
+	if start < 0 || end < start {
+		return loudnessStats{}, fmt.Errorf("loudness analysis printed no measurement")
+	}
+	var stats loudnessStats
+	if err := json.Unmarshal([]byte(output[start:end+1]), &stats); err != nil {
+		return loudnessStats{}, fmt.Errorf("failed to parse loudness measurement: %w", err)
+	}
+	if stats.InputI == "" || stats.InputI == "-inf" {
+		return loudnessStats{}, fmt.Errorf("audio is silent, nothing to normalize")
+	}
+	return stats, nil
+}
+
+// parseSampleRate returns the sample rate of the first audio stream ffmpeg
+// describes in output, or 0.
+func parseSampleRate(output string) int {
+	m := reSampleRate.FindStringSubmatch(output)
+	if m == nil {
+		return 0
+	}
+	rate, _ := strconv.Atoi(m[1])
+	return rate
+}
+
+// audioEncoderArgs picks the encoder used to write normalized audio back
+// into a file with extension ext.
+func audioEncoderArgs(ext string) []string {
+	switch strings.ToLower(strings.TrimPrefix(ext, ".")) {
+	case "mp3":
+		return []string{"-c:a", "libmp3lame", "-q:a", "0", "-id3v2_version", "3"}
+	case "opus", "webm":
+		return []string{"-c:a", "libopus", "-b:a", "160k"}
+	case "ogg", "oga":
+		return []string{"-c:a", "libvorbis", "-q:a", "6"}
+	case "flac":
+		return []string{"-c:a", "flac"}
+	case "wav":
+		return []string{"-c:a", "pcm_s16le"}
+	default:
+		return []string{"-c:a", "aac", "-b:a", "256k"}
+	}
+}
+
+// measureLoudness runs the loudnorm analysis pass over paths, played back to
+// back as one stream, and returns the measurement with ffmpeg's output.
+func measureLoudness(ctx context.Context, paths []string, target float64) (loudnessStats, string, error) {
+	out, err := runner.Command(ctx, "ffmpeg", loudnessAnalysisArgs(paths, target)...).CombinedOutput()
+	if err != nil {
+		return loudnessStats{}, "", fmt.Errorf("ffmpeg loudness analysis failed: %s", ffmpegErrorMessage(out, err))
+	}
+	stats, err := parseLoudnessStats(string(out))
+	if err != nil {
+		return loudnessStats{}, "", err
+	}
+	return stats, string(out), nil
+}
+
+// loudnessAnalysisArgs returns the ffmpeg arguments of the loudnorm
+// analysis pass over paths.
+func loudnessAnalysisArgs(paths []string, target float64) []string {
+	args := []string{"-hide_banner", "-nostdin"}
+	for _, path := range paths {
+		args = append(args, "-i", path)
+	}
+	if len(paths) == 1 {
+		args = append(args, "-map", "0:a:0", "-af", loudnormFilter(target, nil))
+	} else {
+		var inputs strings.Builder
+		for i := range paths {
+			fmt.Fprintf(&inputs, "[%d:a:0]", i)
+		}
+		graph := fmt.Sprintf("%sconcat=n=%d:v=0:a=1,%s[out]", inputs.String(), len(paths), loudnormFilter(target, nil))
+		args = append(args, "-filter_complex", graph, "-map", "[out]")
+	}
+	return append(args, "-f", "null", "-")
+}
+
+// normalizeLoudness runs a two-pass EBU R128 loudness normalization on path
+// in place. The first pass measures the audio, the second applies the
+// correction while copying every other stream and all tags.
+func normalizeLoudness(ctx context.Context, path string, target float64) (loudnessStats, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return loudnessStats{}, fmt.Errorf("failed to resolve downloaded file path: %w", err)
+	}
+	if _, err := os.Stat(absPath); err != nil {
+		return loudnessStats{}, fmt.Errorf("downloaded file not found for loudness normalization: %w", err)
+	}
+
+	stats, out, err := measureLoudness(ctx, []string{absPath}, target)
+	if err != nil {
+		return loudnessStats{}, err
+	}
+
+	ext := filepath.Ext(absPath)
+	base := strings.TrimSuffix(filepath.Base(absPath), ext)
+	tmpFile, err := os.CreateTemp(filepath.Dir(absPath), base+".ytcli-normalize-*"+ext)
+	if err != nil {
+		return loudnessStats{}, fmt.Errorf("failed to create temporary normalization file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	tmpFile.Close()
+	defer os.Remove(tmpPath)
+
+	rate := ""
+	if n := parseSampleRate(out); n > 0 {
+		rate = strconv.Itoa(n)
+	}
+	correction := runner.Command(ctx, "ffmpeg", loudnessCorrectionArgs(absPath, tmpPath, target, stats, rate)...)
+	if out, err := correction.CombinedOutput(); err != nil {
+		return loudnessStats{}, fmt.Errorf("ffmpeg loudness correction failed: %s", ffmpegErrorMessage(out, err))
+	}
+	if err := os.Rename(tmpPath, absPath); err != nil {
+		return loudnessStats{}, fmt.Errorf("failed to finalize normalized file: %w", err)
+	}
+	return stats, nil
+}
+
+// loudnessCorrectionArgs returns the ffmpeg arguments of the second pass,
+// which writes path normalized with the measured stats to out. rate is the
+// source sample rate, or empty when unknown.
+func loudnessCorrectionArgs(path, out string, target float64, stats loudnessStats, rate string) []string {
+	args := []string{
+		"-hide_banner",
+		"-loglevel", "error",
+		"-nostdin",
+		"-y",
+		"-i", path,
+		"-map", "0",
+		"-map_metadata", "0",
+		"-c", "copy",
+		"-af", loudnormFilter(target, &stats),
+	}
+	args = append(args, audioEncoderArgs(filepath.Ext(out))...)
+	// loudnorm resamples to 192 kHz internally; keep the source rate.
+	if rate != "" {
+		args = append(args, "-ar", rate)
+	}
+	return append(args, out)
+}
+
+// normalizeStep normalizes the download and reports the measured loudness.
+// A failure leaves the file untouched.
+func normalizeStep(ctx context.Context, job *postJob) error {
+	stats, err := normalizeLoudness(ctx, job.Path, job.Cfg.TargetLUFS)
+	if err != nil {
+		return fmt.Errorf("failed to normalize loudness (%v)", err)
+	}
+	fmt.Fprintf(job.Stdout, "Normalized loudness: %s LUFS -> %s LUFS\n", stats.InputI, strconv.FormatFloat(job.Cfg.TargetLUFS, 'f', -1, 64))
+	return nil
+}
+
+// ffmpegErrorMessage returns the last line ffmpeg printed, which carries
+// the reason it failed.
+func ffmpegErrorMessage(out []byte, err error) string {
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if message := strings.TrimSpace(lines[len(lines)-1]); message != "" {
+		return message
+	}
+	return err.Error()
+}
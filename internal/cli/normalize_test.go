@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const loudnormAnalysisOutput = `Input #0, mp3, from 'Song.mp3':
+  Duration: 00:03:12.04, start: 0.025057, bitrate: 245 kb/s
+  Stream #0:0: Audio: mp3, 44100 Hz, stereo, fltp, 245 kb/s
+  Stream #0:1: Video: mjpeg (Baseline), yuvj420p(pc), 1280x720, 90k tbr (attached pic)
+[Parsed_loudnorm_0 @ 0x55d0c8a4b2c0]
+{
+	"input_i" : "-9.87",
+	"input_tp" : "0.42",
+	"input_lra" : "5.10",
+	"input_thresh" : "-20.02",
+	"output_i" : "-14.03",
+	"output_tp" : "-1.00",
+	"output_lra" : "4.60",
+	"output_thresh" : "-24.13",
+	"normalization_type" : "dynamic",
+	"target_offset" : "0.03"
+}
+`
+
+func TestParseLoudnessStats(t *testing.T) {
+	stats, err := parseLoudnessStats(loudnormAnalysisOutput)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := loudnessStats{InputI: "-9.87", InputTP: "0.42", InputLRA: "5.10", InputThresh: "-20.02", TargetOffset: "0.03"}
+	if stats != want {
+		t.Fatalf("got %+v, want %+v", stats, want)
+	}
+	if rate := parseSampleRate(loudnormAnalysisOutput); rate != 44100 {
+		t.Fatalf("got sample rate %d", rate)
+	}
+
+	if _, err := parseLoudnessStats("Output file is empty, nothing was encoded"); err == nil {
+		t.Fatalf("expected error for output without measurement")
+	}
+	if _, err := parseLoudnessStats(`{"input_i" : "-inf", "input_tp" : "-inf"}`); err == nil || !strings.Contains(err.Error(), "silent") {
+		t.Fatalf("expected silence error, got %v", err)
+	}
+}
+
+func TestLoudnormFilter(t *testing.T) {
+	if got := loudnormFilter(-14, nil); got != "loudnorm=I=-14:TP=-1.0:LRA=11:print_format=json" {
+		t.Fatalf("got %q", got)
+	}
+	stats := loudnessStats{InputI: "-9.87", InputTP: "0.42", InputLRA: "5.10", InputThresh: "-20.02", TargetOffset: "0.03"}
+	want := "loudnorm=I=-23.5:TP=-1.0:LRA=11:measured_I=-9.87:measured_TP=0.42:measured_LRA=5.10:measured_thresh=-20.02:offset=0.03:linear=true:print_format=summary"
+	if got := loudnormFilter(-23.5, &stats); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestAudioEncoderArgs(t *testing.T) {
+	tests := map[string]string{
+		".mp3":  "-c:a libmp3lame -q:a 0 -id3v2_version 3",
+		".M4A":  "-c:a aac -b:a 256k",
+		".mp4":  "-c:a aac -b:a 256k",
+		".opus": "-c:a libopus -b:a 160k",
+		".flac": "-c:a flac",
+		".wav":  "-c:a pcm_s16le",
+	}
+	for ext, want := range tests {
+		if got := strings.Join(audioEncoderArgs(ext), " "); got != want {
+			t.Fatalf("%s: got %q, want %q", ext, got, want)
+		}
+	}
+}
+
+func TestParseConfigNormalize(t *testing.T) {
+	cfg, _, err := parseConfig([]string{"--mode", "audio", "--normalize", "--target-lufs", "-16", "https://youtu.be/example"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Normalize || cfg.TargetLUFS != -16 || archiveVariant(cfg) != "audio+normalized" {
+		t.Fatalf("unexpected config: %+v (variant %q)", cfg, archiveVariant(cfg))
+	}
+
+	cfg, _, err = parseConfig([]string{"--normalize", "https://youtu.be/example"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.TargetLUFS != defaultTargetLUFS {
+		t.Fatalf("got target %v", cfg.TargetLUFS)
+	}
+
+	expectParseError(t, "video mode has no audio", "--mode", "video", "--normalize", "https://youtu.be/example")
+	expectParseError(t, "requires --normalize", "--target-lufs", "-16", "https://youtu.be/example")
+	expectParseError(t, "must be between -70 and", "--normalize", "--target-lufs", "3", "https://youtu.be/example")
+	expectParseError(t, "invalid value \"loud\"", "--normalize", "--target-lufs", "loud", "https://youtu.be/example")
+}
@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type itemRange struct {
+	From int
+	To   int // 0 means open-ended
+}
+
+type playlistEntry struct {
+	Index    int
+	ID       string
+	URL      string
+	Title    string
+	Uploader string
+}
+
+func isYouTubeHost(host string) bool {
+	host = strings.ToLower(strings.TrimPrefix(host, "www."))
+	switch host {
+	case "youtube.com", "m.youtube.com", "music.youtube.com", "youtu.be":
+		return true
+	}
+	return false
+}
+
+// collectionURL reports whether rawURL points at a playlist or channel and
+// returns the URL ytcli should enumerate. Bare channel URLs are pointed at the
+// channel's videos tab so yt-dlp lists uploads rather than the channel tabs.
+// A video link that also carries a list, as YouTube hands out while playing a
+// playlist or an endless mix, stays a single video.
+func collectionURL(rawURL string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || !isYouTubeHost(u.Host) {
+		return "", false
+	}
+
+	if query := u.Query(); query.Get("list") != "" {
+		videoID := query.Get("v")
+		if strings.EqualFold(strings.TrimPrefix(u.Host, "www."), "youtu.be") {
+			videoID = strings.Trim(u.Path, "/")
+		}
+		return u.String(), videoID == ""
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) == 0 || segments[0] == "" {
+		return "", false
+	}
+
+	channelRoot := 0
+	switch {
+	case strings.HasPrefix(segments[0], "@"):
+		channelRoot = 1
+	case segments[0] == "channel" || segments[0] == "c" || segments[0] == "user":
+		if len(segments) < 2 {
+			return "", false
+		}
+		channelRoot = 2
+	default:
+		return "", false
+	}
+
+	if len(segments) == channelRoot {
+		u.Path = "/" + strings.Join(append(segments, "videos"), "/")
+	}
+	return u.String(), true
+}
+
+func isCollectionURL(rawURL string) bool {
+	_, ok := collectionURL(rawURL)
+	return ok
+}
+
+func parseItemRanges(spec string) ([]itemRange, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	ranges := []itemRange{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("invalid --items %q; empty range", spec)
+		}
+
+		fromText, toText, isRange := strings.Cut(part, "-")
+		from, err := strconv.Atoi(strings.TrimSpace(fromText))
+		if err != nil || from < 1 {
+			return nil, fmt.Errorf("invalid --items range %q; use positive numbers like 1-10,15", part)
+		}
+
+		r := itemRange{From: from, To: from}
+		if isRange {
+			toText = strings.TrimSpace(toText)
+			if toText == "" {
+				r.To = 0
+			} else {
+				to, err := strconv.Atoi(toText)
+				if err != nil || to < from {
+					return nil, fmt.Errorf("invalid --items range %q; end must be a number not less than start", part)
+				}
+				r.To = to
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+func (r itemRange) contains(index int) bool {
+	return index >= r.From && (r.To == 0 || index <= r.To)
+}
+
+// selectEntries keeps the entries whose 1-based playlist index falls inside
+// one of ranges (all entries when ranges is empty), in playlist order, then
+// reverses the selection when requested.
+func selectEntries(entries []playlistEntry, ranges []itemRange, reverse bool) []playlistEntry {
+	selected := []playlistEntry{}
+	for _, entry := range entries {
+		if len(ranges) == 0 {
+			selected = append(selected, entry)
+			continue
+		}
+		for _, r := range ranges {
+			if r.contains(entry.Index) {
+				selected = append(selected, entry)
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		if reverse {
+			return selected[i].Index > selected[j].Index
+		}
+		return selected[i].Index < selected[j].Index
+	})
+	return selected
+}
+
+type flatPlaylist struct {
+	Title   string `json:"title"`
+	Entries []struct {
+		ID       string `json:"id"`
+		URL      string `json:"url"`
+		Title    string `json:"title"`
+		Uploader string `json:"uploader"`
+		Channel  string `json:"channel"`
+	} `json:"entries"`
+}
+
+func parseFlatPlaylist(data []byte) ([]playlistEntry, error) {
+	var playlist flatPlaylist
+	if err := json.Unmarshal(data, &playlist); err != nil {
+		return nil, fmt.Errorf("failed to parse playlist listing: %w", err)
+	}
+
+	entries := make([]playlistEntry, 0, len(playlist.Entries))
+	for i, raw := range playlist.Entries {
+		entryURL := strings.TrimSpace(raw.URL)
+		if entryURL == "" && raw.ID != "" {
+			entryURL = "https://www.youtube.com/watch?v=" + raw.ID
+		}
+		uploader := raw.Uploader
+		if uploader == "" {
+			uploader = raw.Channel
+		}
+		entries = append(entries, playlistEntry{
+			Index:    i + 1,
+			ID:       raw.ID,
+			URL:      entryURL,
+			Title:    raw.Title,
+			Uploader: uploader,
+		})
+	}
+	return entries, nil
+}
+
+func fetchPlaylistEntries(ctx context.Context, ytDlpBinary, listURL string) ([]playlistEntry, error) {
+	cmd := runner.Command(
+		ctx,
+		ytDlpBinary,
+		"--flat-playlist",
+		"--dump-single-json",
+		"--no-warnings",
+		listURL,
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list playlist entries: %w", err)
+	}
+	return parseFlatPlaylist(out)
+}
+
+// unavailableEntryReason recognizes the placeholder titles yt-dlp reports for
+// private or deleted playlist entries.
+func unavailableEntryReason(entry playlistEntry) (string, bool) {
+	switch strings.TrimSpace(entry.Title) {
+	case "[Private video]":
+		return "private video", true
+	case "[Deleted video]":
+		return "deleted video", true
+	}
+	if entry.URL == "" {
+		return "entry has no url", true
+	}
+	return "", false
+}
+
+// expandBatchItems replaces every playlist or channel item with its selected
+// entries. Items that fail to expand are kept with Err set so they show up in
+// the summary at their original position.
+func expandBatchItems(ctx context.Context, ytDlpBinary string, items []batchItem, ranges []itemRange, reverse bool) []batchItem {
+	expanded := make([]batchItem, 0, len(items))
+	for _, item := range items {
+		listURL, ok := collectionURL(item.URL)
+		if !ok {
+			expanded = append(expanded, item)
+			continue
+		}
+
+		entries, err := fetchPlaylistEntries(ctx, ytDlpBinary, listURL)
+		if err != nil {
+			item.Err = err
+			expanded = append(expanded, item)
+			continue
+		}
+		selected := selectEntries(entries, ranges, reverse)
+		if len(selected) == 0 {
+			item.Err = fmt.Errorf("no playlist entries matched the selection")
+			expanded = append(expanded, item)
+			continue
+		}
+
+		for _, entry := range selected {
+			entryItem := batchItem{
+				Line:  item.Line,
+				Index: entry.Index,
+				URL:   entry.URL,
+			}
+			if reason, unavailable := unavailableEntryReason(entry); unavailable {
+				entryItem.SkipReason = reason
+			}
+			expanded = append(expanded, entryItem)
+		}
+	}
+	return expanded
+}
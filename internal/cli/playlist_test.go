@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCollectionURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		want   string
+		wantOK bool
+	}{
+		{name: "playlist", input: "https://www.youtube.com/playlist?list=PL123", want: "https://www.youtube.com/playlist?list=PL123", wantOK: true},
+		{name: "list without video", input: "https://music.youtube.com/watch?list=OLAK5uy_abc", want: "https://music.youtube.com/watch?list=OLAK5uy_abc", wantOK: true},
+		{name: "watch in playlist", input: "https://www.youtube.com/watch?v=abc&list=PL123&index=2", wantOK: false},
+		{name: "watch in mix", input: "https://www.youtube.com/watch?v=abc&list=RDabc&start_radio=1", wantOK: false},
+		{name: "short link in playlist", input: "https://youtu.be/abc?list=PL123", wantOK: false},
+		{name: "handle root", input: "https://www.youtube.com/@artist", want: "https://www.youtube.com/@artist/videos", wantOK: true},
+		{name: "channel tab", input: "https://www.youtube.com/channel/UC123/streams", want: "https://www.youtube.com/channel/UC123/streams", wantOK: true},
+		{name: "single video", input: "https://youtu.be/abc", wantOK: false},
+		{name: "watch", input: "https://www.youtube.com/watch?v=abc", wantOK: false},
+		{name: "other host", input: "https://example.com/@artist", wantOK: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := collectionURL(tc.input)
+			if ok != tc.wantOK {
+				t.Fatalf("ok got %v, want %v", ok, tc.wantOK)
+			}
+			if ok && got != tc.want {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseItemRanges(t *testing.T) {
+	ranges, err := parseItemRanges("1-3, 7,10-")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []itemRange{{From: 1, To: 3}, {From: 7, To: 7}, {From: 10, To: 0}}
+	if len(ranges) != len(want) {
+		t.Fatalf("got %v, want %v", ranges, want)
+	}
+	for i := range want {
+		if ranges[i] != want[i] {
+			t.Fatalf("range %d got %v, want %v", i, ranges[i], want[i])
+		}
+	}
+
+	for _, bad := range []string{"0", "3-1", "a-b", "1,,2"} {
+		if _, err := parseItemRanges(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestSelectEntries(t *testing.T) {
+	entries := []playlistEntry{}
+	for i := 1; i <= 6; i++ {
+		entries = append(entries, playlistEntry{Index: i})
+	}
+
+	got := selectEntries(entries, []itemRange{{From: 2, To: 3}, {From: 5, To: 0}}, true)
+	wantIndexes := []int{6, 5, 3, 2}
+	if len(got) != len(wantIndexes) {
+		t.Fatalf("got %d entries, want %d", len(got), len(wantIndexes))
+	}
+	for i, idx := range wantIndexes {
+		if got[i].Index != idx {
+			t.Fatalf("entry %d index got %d, want %d", i, got[i].Index, idx)
+		}
+	}
+}
+
+func TestParseFlatPlaylist(t *testing.T) {
+	data := []byte(`{"title":"Mix","entries":[
+		{"id":"abc","url":"https://www.youtube.com/watch?v=abc","title":"Daft Punk - One More Time","channel":"Daft Punk"},
+		{"id":"def","title":"[Private video]"}
+	]}`)
+
+	entries, err := parseFlatPlaylist(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if entries[0].Uploader != "Daft Punk" || entries[0].Index != 1 {
+		t.Fatalf("unexpected first entry: %+v", entries[0])
+	}
+	if entries[1].URL != "https://www.youtube.com/watch?v=def" {
+		t.Fatalf("url got %q", entries[1].URL)
+	}
+	if _, unavailable := unavailableEntryReason(entries[1]); !unavailable {
+		t.Fatal("expected private entry to be reported unavailable")
+	}
+}
+
+func TestParseConfigRejectsItemsForSingleVideo(t *testing.T) {
+	_, _, err := parseConfig([]string{"--items", "1-3", "https://youtu.be/example"}, &bytes.Buffer{})
+	if err == nil {
+		t.Fatal("expected error for --items with a single video url")
+	}
+
+	_, _, err = parseConfig([]string{"--items", "1-3", "https://www.youtube.com/playlist?list=PL123"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
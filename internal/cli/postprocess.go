@@ -0,0 +1,267 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// postJob is a finished download on its way through the post-processing
+// pipeline. Steps read and update it in place.
+type postJob struct {
+	Cfg config
+	// Path is the downloaded file, or the chapter folder after a split.
+	// It is empty when yt-dlp did not report where it saved the file.
+	Path string
+	// Files are the media files Path stands for: the download itself, or
+	// one file per chapter after a split.
+	Files []string
+	Meta  *trackMetadata
+	// Info holds the chapter list when the download is to be split.
+	Info   *videoInfo
+	Cover  *coverArt
+	Result *downloadResult
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// cleanup lists files removed once the pipeline has finished.
+	cleanup []string
+}
+
+func (j *postJob) warn(format string, args ...any) {
+	j.Result.warn(j.Stderr, format, args...)
+}
+
+// postProcessor is one step of the post-processing pipeline.
+type postProcessor interface {
+	// Name describes the step in messages, e.g. "metadata tagging".
+	Name() string
+	// Enabled reports whether the step applies to job.
+	Enabled(job *postJob) bool
+	// Run processes job. An error that carries an error kind (see withKind)
+	// fails the download and stops the pipeline; any other error is
+	// reported as a warning and the next step runs. Once ctx is cancelled
+	// the pipeline stops with an interrupted error.
+	Run(ctx context.Context, job *postJob) error
+	// Plan returns the external commands Run would execute for job, for
+	// --dry-run, and updates job the way Run would without touching any
+	// file.
+	Plan(job *postJob) [][]string
+}
+
+// postStep adapts functions to postProcessor. Steps with needsPath are
+// skipped with a warning when the download path is unknown; steps without
+// plan run no external commands.
+type postStep struct {
+	name      string
+	needsPath bool
+	enabled   func(job *postJob) bool
+	run       func(ctx context.Context, job *postJob) error
+	plan      func(job *postJob) [][]string
+}
+
+func (s postStep) Name() string { return s.name }
+
+func (s postStep) Enabled(job *postJob) bool { return s.enabled(job) }
+
+func (s postStep) Run(ctx context.Context, job *postJob) error {
+	if s.needsPath && strings.TrimSpace(job.Path) == "" {
+		return fmt.Errorf("download completed but output path was unavailable, skipping %s", s.name)
+	}
+	return s.run(ctx, job)
+}
+
+func (s postStep) Plan(job *postJob) [][]string {
+	if s.plan == nil {
+		return nil
+	}
+	return s.plan(job)
+}
+
+// postPipeline runs its steps in registration order.
+type postPipeline struct {
+	steps []postProcessor
+}
+
+func (p *postPipeline) register(steps ...postProcessor) {
+	p.steps = append(p.steps, steps...)
+}
+
+func (p *postPipeline) run(ctx context.Context, job *postJob) error {
+	defer func() {
+		for _, path := range job.cleanup {
+			os.Remove(path)
+		}
+	}()
+
+	for _, step := range p.steps {
+		if err := ctx.Err(); err != nil {
+			return interruptedError(ctx, err)
+		}
+		if !step.Enabled(job) {
+			continue
+		}
+		if err := step.Run(ctx, job); err != nil {
+			err = interruptedError(ctx, err)
+			var ke *kindError
+			if errors.As(err, &ke) {
+				return err
+			}
+			job.warn("%v", err)
+		}
+	}
+	return nil
+}
+
+// plan collects the commands of every enabled step in order.
+func (p *postPipeline) plan(job *postJob) [][]string {
+	var commands [][]string
+	for _, step := range p.steps {
+		if step.Enabled(job) {
+			commands = append(commands, step.Plan(job)...)
+		}
+	}
+	return commands
+}
+
+// newPostPipeline returns the steps run after every download. Audio is
+// changed before it is tagged, ReplayGain is measured on the final audio,
+// and import comes last so Music.app sees the finished files.
+func newPostPipeline() *postPipeline {
+	p := &postPipeline{}
+	p.register(
+		postStep{
+			name:    "subtitles",
+			enabled: func(job *postJob) bool { return job.Cfg.Subs != "" && !job.Cfg.EmbedSubs && job.Path != "" },
+			run:     collectSubtitles,
+		},
+		postStep{
+			name:      "silence trimming and fades",
+			needsPath: true,
+			enabled:   func(job *postJob) bool { return job.Cfg.hasEffects() },
+			run:       applyEffectsStep,
+			plan:      planEffects,
+		},
+		postStep{
+			// A chapter split is normalized as a whole before splitting,
+			// which keeps the chapters' relative loudness intact.
+			name:      "loudness normalization",
+			needsPath: true,
+			enabled:   func(job *postJob) bool { return job.Cfg.Normalize },
+			run:       normalizeStep,
+			plan:      planNormalize,
+		},
+		postStep{
+			name:    "cover art",
+			enabled: func(job *postJob) bool { return job.Cfg.Mode == "audio" && job.Path != "" },
+			run:     resolveCoverStep,
+			plan:    planCover,
+		},
+		postStep{
+			name:    "chapter split",
+			enabled: func(job *postJob) bool { return job.Info != nil },
+			run:     splitChaptersStep,
+			plan:    planChapterSplit,
+		},
+		postStep{
+			name:      "metadata tagging",
+			needsPath: true,
+			enabled:   func(job *postJob) bool { return job.Cfg.Mode == "audio" && job.Info == nil },
+			run:       tagStep,
+			plan:      planTag,
+		},
+		postStep{
+			name:      "ReplayGain",
+			needsPath: true,
+			enabled:   func(job *postJob) bool { return job.Cfg.ReplayGain },
+			run:       replayGainStep,
+			plan:      planReplayGain,
+		},
+		postStep{
+			name:    "Apple Music import",
+			enabled: func(job *postJob) bool { return job.Cfg.AppleMusic },
+			run:     importStep,
+			plan:    planImport,
+		},
+	)
+	return p
+}
+
+// tagStep fills in missing artist/title tags from the file name and writes
+// the tags, with the cover when one was resolved.
+func tagStep(ctx context.Context, job *postJob) error {
+	meta, err := prepareTags(job)
+	if meta == nil {
+		return err
+	}
+	if err := writeAudioMetadata(ctx, job.Path, *meta, job.Cover); err != nil {
+		return fmt.Errorf("failed to write audio metadata tags (%v)", err)
+	}
+	fmt.Fprintf(job.Stdout, "Tagged audio metadata: %s - %s\n", meta.Artist, meta.Title)
+	if job.Cover != nil {
+		fmt.Fprintf(job.Stdout, "Embedded cover art: %s\n", filepath.Base(job.Cover.Path))
+	}
+	return nil
+}
+
+// prepareTags completes job.Meta for tagging: missing artist/title are
+// inferred from the file name, and the source url and tag overrides are
+// applied. It returns nil when there is nothing to write.
+func prepareTags(job *postJob) (*trackMetadata, error) {
+	cfg, meta := job.Cfg, job.Meta
+	needsInference := meta == nil ||
+		strings.TrimSpace(meta.Title) == "" ||
+		strings.TrimSpace(meta.Artist) == "" ||
+		(meta.Artist == "Unknown Artist" && strings.TrimSpace(cfg.Artist) == "")
+	if needsInference {
+		inferredMeta, ok := inferTrackMetadataFromPath(job.Path)
+		if !ok {
+			job.warn("metadata unavailable and could not infer tags from file name")
+		} else if meta == nil {
+			meta = &inferredMeta
+			job.warn("metadata fetch failed, inferred tags from filename: %s - %s", meta.Artist, meta.Title)
+		} else {
+			if strings.TrimSpace(meta.Title) == "" {
+				meta.Title = inferredMeta.Title
+			}
+			if strings.TrimSpace(meta.Artist) == "" || (meta.Artist == "Unknown Artist" && strings.TrimSpace(cfg.Artist) == "") {
+				meta.Artist = inferredMeta.Artist
+			}
+		}
+	}
+	job.Meta = meta
+
+	if meta == nil {
+		return nil, nil
+	}
+	if strings.TrimSpace(meta.Title) == "" {
+		return nil, fmt.Errorf("metadata title is empty, skipping audio metadata tagging")
+	}
+	if meta.Comment == "" {
+		meta.Comment = cfg.URL
+	}
+	applyTagOverrides(meta, cfg.Album, cfg.Year, cfg.Genre)
+	return meta, nil
+}
+
+// importStep adds every finished file to the Apple Music library. A failed
+// import fails the download.
+func importStep(ctx context.Context, job *postJob) error {
+	if strings.TrimSpace(job.Path) == "" {
+		job.Result.AppleMusic = "failed"
+		return withKind(errKindImport, fmt.Errorf("download completed but could not determine output path for Apple Music import"))
+	}
+	for _, path := range job.Files {
+		if err := importIntoAppleMusic(ctx, path); err != nil {
+			job.Result.AppleMusic = "failed"
+			return withKind(errKindImport, err)
+		}
+		fmt.Fprintf(job.Stdout, "Imported into Apple Music: %s\n", path)
+	}
+	job.Result.AppleMusic = "imported"
+	return nil
+}
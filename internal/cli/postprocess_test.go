@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPostPipelineRun(t *testing.T) {
+	var calls []string
+	step := func(name string, enabled bool, err error) postStep {
+		return postStep{
+			name:    name,
+			enabled: func(*postJob) bool { return enabled },
+			run: func(context.Context, *postJob) error {
+				calls = append(calls, name)
+				return err
+			},
+		}
+	}
+
+	leftover := filepath.Join(t.TempDir(), "thumb.jpg")
+	if err := os.WriteFile(leftover, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stderr bytes.Buffer
+	job := &postJob{Path: "song.mp3", Result: &downloadResult{}, Stderr: &stderr, cleanup: []string{leftover}}
+	p := &postPipeline{}
+	p.register(
+		step("first", true, nil),
+		step("disabled", false, nil),
+		step("soft", true, errors.New("failed to do a soft thing")),
+		postStep{name: "path step", needsPath: true, enabled: func(*postJob) bool { return true }, run: func(context.Context, *postJob) error {
+			calls = append(calls, "path step")
+			return nil
+		}},
+		step("fatal", true, withKind(errKindImport, errors.New("import broke"))),
+		step("after fatal", true, nil),
+	)
+
+	err := p.run(context.Background(), job)
+	if err == nil || kindOf(err) != errKindImport {
+		t.Fatalf("expected fatal import error, got %v", err)
+	}
+	if got := strings.Join(calls, ","); got != "first,soft,path step,fatal" {
+		t.Fatalf("got calls %q", got)
+	}
+	if len(job.Result.Warnings) != 1 || job.Result.Warnings[0] != "failed to do a soft thing" {
+		t.Fatalf("unexpected warnings: %v", job.Result.Warnings)
+	}
+	if !strings.Contains(stderr.String(), "Warning: failed to do a soft thing") {
+		t.Fatalf("warning not printed: %q", stderr.String())
+	}
+	if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+		t.Fatalf("cleanup file was not removed: %v", err)
+	}
+}
+
+func TestPostStepNeedsPath(t *testing.T) {
+	ran := false
+	s := postStep{name: "loudness normalization", needsPath: true, run: func(context.Context, *postJob) error {
+		ran = true
+		return nil
+	}}
+	err := s.Run(context.Background(), &postJob{})
+	if ran || err == nil || err.Error() != "download completed but output path was unavailable, skipping loudness normalization" {
+		t.Fatalf("got ran=%v, err=%v", ran, err)
+	}
+	if kindOf(err) != errKindInternal {
+		t.Fatalf("missing path should only warn, got kind %q", kindOf(err))
+	}
+}
+
+func TestNewPostPipelineOrder(t *testing.T) {
+	var names []string
+	for _, step := range newPostPipeline().steps {
+		names = append(names, step.Name())
+	}
+	want := "subtitles,silence trimming and fades,loudness normalization,cover art,chapter split,metadata tagging,ReplayGain,Apple Music import"
+	if got := strings.Join(names, ","); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	job := &postJob{Cfg: config{Mode: "audio", AppleMusic: true}, Path: "song.mp3"}
+	var enabled []string
+	for _, step := range newPostPipeline().steps {
+		if step.Enabled(job) {
+			enabled = append(enabled, step.Name())
+		}
+	}
+	if got := strings.Join(enabled, ","); got != "cover art,metadata tagging,Apple Music import" {
+		t.Fatalf("got enabled steps %q", got)
+	}
+}
+
+func TestPostPipelineStopsOnInterrupt(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls []string
+	p := &postPipeline{}
+	p.register(
+		postStep{name: "normalize", enabled: func(*postJob) bool { return true }, run: func(context.Context, *postJob) error {
+			calls = append(calls, "normalize")
+			cancel()
+			return errors.New("ffmpeg loudness analysis failed: signal: terminated")
+		}},
+		postStep{name: "tag", enabled: func(*postJob) bool { return true }, run: func(context.Context, *postJob) error {
+			calls = append(calls, "tag")
+			return nil
+		}},
+	)
+
+	job := &postJob{Path: "song.mp3", Result: &downloadResult{}, Stderr: &bytes.Buffer{}}
+	err := p.run(ctx, job)
+	if !errors.Is(err, errInterrupted) || kindOf(err) != errKindInterrupted {
+		t.Fatalf("expected interrupted error, got %v", err)
+	}
+	if strings.Join(calls, ",") != "normalize" || len(job.Result.Warnings) != 0 {
+		t.Fatalf("got calls %v, warnings %v", calls, job.Result.Warnings)
+	}
+}
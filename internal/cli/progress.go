@@ -0,0 +1,262 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const progressPrefix = "__YTCLI_PROGRESS__:"
+
+type progressPhase string
+
+const (
+	phaseDownload    progressPhase = "download"
+	phaseMerge       progressPhase = "merge"
+	phaseExtract     progressPhase = "extract"
+	phasePostprocess progressPhase = "postprocess"
+)
+
+// progressEvent is one progress update reported by yt-dlp through the
+// templates from progressArgs.
+type progressEvent struct {
+	Phase         progressPhase
+	Status        string
+	Downloaded    int64
+	Total         int64
+	Estimated     bool
+	Speed         float64 // bytes per second, 0 when unknown
+	ETA           int     // seconds, -1 when unknown
+	Postprocessor string
+	// Filename is the file a download update is for; yt-dlp writes it as
+	// Filename + ".part" until it is complete.
+	Filename string
+}
+
+// progressArgs makes yt-dlp print one machine-readable line per progress
+// update instead of redrawing its own progress bar. --progress keeps the
+// updates coming when --print has put yt-dlp into quiet mode.
+func progressArgs() []string {
+	download := strings.Join([]string{
+		progressPrefix + "download",
+		"%(progress.status)s",
+		"%(progress.downloaded_bytes)s",
+		"%(progress.total_bytes)s",
+		"%(progress.total_bytes_estimate)s",
+		"%(progress.speed)s",
+		"%(progress.eta)s",
+		"%(progress.filename)s",
+	}, "\t")
+	postprocess := strings.Join([]string{
+		progressPrefix + "postprocess",
+		"%(progress.status)s",
+		"%(progress.postprocessor)s",
+	}, "\t")
+
+	return []string{
+		"--progress",
+		"--newline",
+		"--progress-template", "download:" + download,
+		"--progress-template", "postprocess:" + postprocess,
+	}
+}
+
+func parseProgressNumber(value string) (float64, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "NA" || value == "None" {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// postprocessorPhase maps yt-dlp postprocessor names onto ytcli phases.
+func postprocessorPhase(name string) progressPhase {
+	switch {
+	case strings.HasPrefix(name, "Merger"), strings.HasSuffix(name, "Merger"):
+		return phaseMerge
+	case strings.Contains(name, "ExtractAudio"):
+		return phaseExtract
+	}
+	return phasePostprocess
+}
+
+func parseProgressLine(line string) (progressEvent, bool) {
+	if !strings.HasPrefix(line, progressPrefix) {
+		return progressEvent{}, false
+	}
+	// The file name comes last so a tab in it does not shift other fields.
+	fields := strings.SplitN(strings.TrimPrefix(line, progressPrefix), "\t", 8)
+
+	switch fields[0] {
+	case "download":
+		if len(fields) != 8 {
+			return progressEvent{}, false
+		}
+		ev := progressEvent{Phase: phaseDownload, Status: fields[1], ETA: -1, Filename: fields[7]}
+		if ev.Filename == "NA" {
+			ev.Filename = ""
+		}
+		if n, ok := parseProgressNumber(fields[2]); ok {
+			ev.Downloaded = int64(n)
+		}
+		if n, ok := parseProgressNumber(fields[3]); ok {
+			ev.Total = int64(n)
+		} else if n, ok := parseProgressNumber(fields[4]); ok {
+			ev.Total = int64(n)
+			ev.Estimated = true
+		}
+		if n, ok := parseProgressNumber(fields[5]); ok {
+			ev.Speed = n
+		}
+		if n, ok := parseProgressNumber(fields[6]); ok {
+			ev.ETA = int(n)
+		}
+		return ev, true
+	case "postprocess":
+		if len(fields) != 3 {
+			return progressEvent{}, false
+		}
+		return progressEvent{
+			Phase:         postprocessorPhase(fields[2]),
+			Status:        fields[1],
+			Postprocessor: fields[2],
+			ETA:           -1,
+		}, true
+	}
+	return progressEvent{}, false
+}
+
+// Percent returns the completed share of the download, or -1 when the total
+// size is unknown.
+func (ev progressEvent) Percent() float64 {
+	if ev.Total <= 0 {
+		return -1
+	}
+	percent := float64(ev.Downloaded) / float64(ev.Total) * 100
+	if percent > 100 {
+		percent = 100
+	}
+	return percent
+}
+
+func formatBytes(n int64) string {
+	value := float64(n)
+	for _, unit := range []string{"B", "KiB", "MiB"} {
+		if value < 1024 {
+			if unit == "B" {
+				return fmt.Sprintf("%d%s", n, unit)
+			}
+			return fmt.Sprintf("%.1f%s", value, unit)
+		}
+		value /= 1024
+	}
+	return fmt.Sprintf("%.1fGiB", value)
+}
+
+func formatETA(seconds int) string {
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
+
+func (ev progressEvent) String() string {
+	if ev.Phase != phaseDownload {
+		return fmt.Sprintf("[%s] %s %s", ev.Phase, ev.Postprocessor, ev.Status)
+	}
+
+	var b strings.Builder
+	b.WriteString("[download] ")
+	if percent := ev.Percent(); percent >= 0 {
+		fmt.Fprintf(&b, "%5.1f%% of ", percent)
+		if ev.Estimated {
+			b.WriteString("~")
+		}
+		b.WriteString(formatBytes(ev.Total))
+	} else {
+		b.WriteString(formatBytes(ev.Downloaded))
+	}
+	if ev.Status == "finished" {
+		return b.String()
+	}
+	if ev.Speed > 0 {
+		fmt.Fprintf(&b, " at %s/s", formatBytes(int64(ev.Speed)))
+	}
+	if ev.ETA >= 0 {
+		fmt.Fprintf(&b, " ETA %s", formatETA(ev.ETA))
+	}
+	return b.String()
+}
+
+// progressRenderer draws progress events. On a terminal the running
+// download line is redrawn in place; otherwise, such as for pipes or
+// prefixed parallel output, a line is printed for every 10% step.
+type progressRenderer struct {
+	w           io.Writer
+	interactive bool
+	inPlace     int // width of the line currently drawn in place, 0 if none
+	lastStep    int
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func newProgressRenderer(w io.Writer) *progressRenderer {
+	return &progressRenderer{w: w, interactive: isTerminal(w), lastStep: -1}
+}
+
+func (r *progressRenderer) Update(ev progressEvent) {
+	line := ev.String()
+
+	if ev.Phase == phaseDownload && ev.Status == "downloading" {
+		if r.interactive {
+			pad := ""
+			if r.inPlace > len(line) {
+				pad = strings.Repeat(" ", r.inPlace-len(line))
+			}
+			fmt.Fprintf(r.w, "\r%s%s", line, pad)
+			r.inPlace = len(line)
+			return
+		}
+		step := int(ev.Percent()) / 10
+		if ev.Percent() < 0 || step <= r.lastStep {
+			return
+		}
+		r.lastStep = step
+		fmt.Fprintln(r.w, line)
+		return
+	}
+
+	if ev.Status == "processing" {
+		return
+	}
+	if ev.Phase == phaseDownload {
+		r.lastStep = -1
+	}
+	if r.interactive && r.inPlace > 0 {
+		fmt.Fprintf(r.w, "\r%s%s\n", line, strings.Repeat(" ", max(r.inPlace-len(line), 0)))
+		r.inPlace = 0
+		return
+	}
+	fmt.Fprintln(r.w, line)
+}
+
+// Flush ends a line drawn in place so other output starts on a fresh line.
+func (r *progressRenderer) Flush() {
+	if r.inPlace > 0 {
+		fmt.Fprintln(r.w)
+		r.inPlace = 0
+	}
+}
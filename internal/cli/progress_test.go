@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseProgressLineDownload(t *testing.T) {
+	line := progressPrefix + "download\tdownloading\t1048576\tNA\t4194304.0\t524288.5\t6\t/music/Daft Punk\t- One More Time.f251.webm"
+
+	ev, ok := parseProgressLine(line)
+	if !ok {
+		t.Fatal("expected progress line to parse")
+	}
+	if ev.Phase != phaseDownload || ev.Status != "downloading" {
+		t.Fatalf("unexpected phase/status: %s/%s", ev.Phase, ev.Status)
+	}
+	if ev.Downloaded != 1048576 || ev.Total != 4194304 || !ev.Estimated {
+		t.Fatalf("unexpected sizes: %+v", ev)
+	}
+	if ev.Filename != "/music/Daft Punk\t- One More Time.f251.webm" {
+		t.Fatalf("unexpected filename: %q", ev.Filename)
+	}
+	if ev.ETA != 6 || ev.Percent() != 25 {
+		t.Fatalf("unexpected eta/percent: %d/%v", ev.ETA, ev.Percent())
+	}
+	if got := ev.String(); got != "[download]  25.0% of ~4.0MiB at 512.0KiB/s ETA 00:06" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestParseProgressLinePostprocess(t *testing.T) {
+	tests := []struct {
+		name  string
+		want  progressPhase
+		input string
+	}{
+		{name: "merge", want: phaseMerge, input: "Merger"},
+		{name: "extract", want: phaseExtract, input: "ExtractAudio"},
+		{name: "other", want: phasePostprocess, input: "VideoConvertor"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ev, ok := parseProgressLine(progressPrefix + "postprocess\tstarted\t" + tc.input)
+			if !ok {
+				t.Fatal("expected progress line to parse")
+			}
+			if ev.Phase != tc.want {
+				t.Fatalf("phase got %q, want %q", ev.Phase, tc.want)
+			}
+		})
+	}
+
+	if _, ok := parseProgressLine("[download] Destination: song.webm"); ok {
+		t.Fatal("did not expect regular output to parse")
+	}
+}
+
+func TestProgressRendererLineMode(t *testing.T) {
+	var buf bytes.Buffer
+	r := newProgressRenderer(&buf)
+
+	for _, downloaded := range []int64{0, 50, 120, 900, 1000} {
+		r.Update(progressEvent{Phase: phaseDownload, Status: "downloading", Downloaded: downloaded, Total: 1000, ETA: -1})
+	}
+	r.Update(progressEvent{Phase: phaseDownload, Status: "finished", Downloaded: 1000, Total: 1000, ETA: -1})
+	r.Update(progressEvent{Phase: phaseExtract, Status: "started", Postprocessor: "ExtractAudio", ETA: -1})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		"[download]   0.0% of 1000B",
+		"[download]  12.0% of 1000B",
+		"[download]  90.0% of 1000B",
+		"[download] 100.0% of 1000B",
+		"[download] 100.0% of 1000B",
+		"[extract] ExtractAudio started",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got lines %q, want %q", lines, want)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Fatalf("line %d got %q, want %q", i, lines[i], want[i])
+		}
+	}
+}
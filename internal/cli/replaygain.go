@@ -0,0 +1,251 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// replayGainReference is the ReplayGain 2.0 reference level in LUFS.
+const replayGainReference = -18.0
+
+// replayGain is the gain that brings audio to the reference level and its
+// linear true peak.
+type replayGain struct {
+	Gain float64
+	Peak float64
+}
+
+func validateReplayGainOptions(cfg config) error {
+	if !cfg.ReplayGain {
+		return nil
+	}
+	if cfg.Mode != "audio" {
+		return fmt.Errorf("--replaygain is only supported with --mode audio")
+	}
+	switch cfg.audioFormat() {
+	case "wav":
+		return fmt.Errorf("--replaygain cannot be used with --audio-format wav; RIFF INFO has no ReplayGain fields")
+	case "m4a":
+		return fmt.Errorf("--replaygain cannot be used with --audio-format m4a; ffmpeg cannot add ReplayGain tags to mp4 files without replacing their iTunes tags")
+	}
+	return nil
+}
+
+// replayGainSupported reports whether ReplayGain tags can be added to a file
+// with extension ext while keeping its other tags. ffmpeg only writes custom
+// mp4 keys with -movflags use_metadata_tags, which stores every tag as an
+// mdta key instead of the iTunes atoms players read.
+func replayGainSupported(ext string) bool {
+	switch strings.ToLower(strings.TrimPrefix(ext, ".")) {
+	case "m4a", "mp4", "wav":
+		return false
+	}
+	return true
+}
+
+// replayGainFiles returns the paths that can carry ReplayGain tags and warns
+// about the others.
+func replayGainFiles(paths []string, warn func(format string, args ...any)) []string {
+	supported := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if !replayGainSupported(filepath.Ext(path)) {
+			warn("ReplayGain tags cannot be written to %s files, skipping %s", strings.TrimPrefix(filepath.Ext(path), "."), filepath.Base(path))
+			continue
+		}
+		supported = append(supported, path)
+	}
+	return supported
+}
+
+// replayGainFromStats converts a loudnorm measurement into ReplayGain values.
+func replayGainFromStats(stats loudnessStats) (replayGain, error) {
+	loudness, err := strconv.ParseFloat(stats.InputI, 64)
+	if err != nil {
+		return replayGain{}, fmt.Errorf("invalid integrated loudness %q", stats.InputI)
+	}
+	truePeak, err := strconv.ParseFloat(stats.InputTP, 64)
+	if err != nil {
+		return replayGain{}, fmt.Errorf("invalid true peak %q", stats.InputTP)
+	}
+	return replayGain{
+		Gain: replayGainReference - loudness,
+		Peak: math.Pow(10, truePeak/20),
+	}, nil
+}
+
+// measureReplayGain measures the gain of paths played back to back, so a
+// single path gives its track gain and a set gives the album gain.
+func measureReplayGain(ctx context.Context, paths ...string) (replayGain, error) {
+	stats, _, err := measureLoudness(ctx, paths, replayGainReference)
+	if err != nil {
+		return replayGain{}, err
+	}
+	return replayGainFromStats(stats)
+}
+
+func formatReplayGain(g replayGain) (gain, peak string) {
+	return fmt.Sprintf("%.2f dB", g.Gain), strconv.FormatFloat(g.Peak, 'f', 6, 64)
+}
+
+// replayGainTags renders the track and, when album is non-nil, album
+// ReplayGain tags as KEY=value pairs.
+func replayGainTags(track *replayGain, album *replayGain) []string {
+	var tags []string
+	if track != nil {
+		gain, peak := formatReplayGain(*track)
+		tags = append(tags, "REPLAYGAIN_TRACK_GAIN="+gain, "REPLAYGAIN_TRACK_PEAK="+peak)
+	}
+	if album != nil {
+		gain, peak := formatReplayGain(*album)
+		tags = append(tags, "REPLAYGAIN_ALBUM_GAIN="+gain, "REPLAYGAIN_ALBUM_PEAK="+peak)
+	}
+	return tags
+}
+
+// replayGainArgs returns the ffmpeg options that write tags to a file with
+// extension ext. ffmpeg writes the keys as TXXX frames in mp3 and Vorbis
+// comments in flac and Ogg.
+func replayGainArgs(tags []string, ext string) []string {
+	var args []string
+	option := "-metadata"
+	switch strings.ToLower(strings.TrimPrefix(ext, ".")) {
+	case "opus", "ogg", "oga":
+		option = "-metadata:s:a:0"
+	case "mp3":
+		args = append(args, "-id3v2_version", "3")
+	}
+	for _, tag := range tags {
+		args = append(args, option, tag)
+	}
+	return args
+}
+
+// writeReplayGainTags adds ReplayGain tags to path, keeping its streams and
+// every existing tag.
+func writeReplayGainTags(ctx context.Context, path string, track *replayGain, album *replayGain) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve downloaded file path: %w", err)
+	}
+
+	ext := filepath.Ext(absPath)
+	base := strings.TrimSuffix(filepath.Base(absPath), ext)
+	tmpFile, err := os.CreateTemp(filepath.Dir(absPath), base+".ytcli-tagging-*"+ext)
+	if err != nil {
+		return fmt.Errorf("failed to create temporary tagging file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	tmpFile.Close()
+	defer os.Remove(tmpPath)
+
+	args := replayGainTagArgs(absPath, tmpPath, replayGainTags(track, album))
+	if out, err := runner.Command(ctx, "ffmpeg", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg ReplayGain tag write failed: %s", ffmpegErrorMessage(out, err))
+	}
+	if err := os.Rename(tmpPath, absPath); err != nil {
+		return fmt.Errorf("failed to finalize tagged audio file: %w", err)
+	}
+	return nil
+}
+
+// replayGainTagArgs returns the ffmpeg arguments that copy path to out with
+// tags added.
+func replayGainTagArgs(path, out string, tags []string) []string {
+	args := []string{
+		"-hide_banner",
+		"-loglevel", "error",
+		"-nostdin",
+		"-y",
+		"-i", path,
+		"-map", "0",
+		"-map_metadata", "0",
+		"-c", "copy",
+	}
+	args = append(args, replayGainArgs(tags, filepath.Ext(out))...)
+	return append(args, out)
+}
+
+// replayGainStep tags the finished files; a chapter split also gets album
+// gain.
+func replayGainStep(ctx context.Context, job *postJob) error {
+	applyReplayGain(ctx, replayGainFiles(job.Files, job.warn), job.Stdout, job.warn)
+	return ctx.Err()
+}
+
+// applyReplayGain measures and tags every file in paths. With more than one
+// file the set is also measured as an album. Failures are reported through
+// warn; once ctx is cancelled the remaining files are left alone.
+func applyReplayGain(ctx context.Context, paths []string, stdout io.Writer, warn func(format string, args ...any)) {
+	var album *replayGain
+	if len(paths) > 1 {
+		gain, err := measureReplayGain(ctx, paths...)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			warn("failed to measure album ReplayGain (%v)", err)
+		} else {
+			album = &gain
+			fmt.Fprintf(stdout, "Album ReplayGain: %.2f dB\n", gain.Gain)
+		}
+	}
+	for _, path := range paths {
+		track, err := measureReplayGain(ctx, path)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			warn("failed to measure ReplayGain for %s (%v)", filepath.Base(path), err)
+			continue
+		}
+		if err := writeReplayGainTags(ctx, path, &track, album); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			warn("failed to write ReplayGain tags for %s (%v)", filepath.Base(path), err)
+			continue
+		}
+		fmt.Fprintf(stdout, "ReplayGain: %.2f dB, peak %.6f (%s)\n", track.Gain, track.Peak, filepath.Base(path))
+	}
+}
+
+// applyAlbumReplayGain adds album gain to the tracks of every playlist that
+// was downloaded in a batch run. Entries are grouped by the batch line they
+// came from; archived and failed entries are not part of the album.
+func applyAlbumReplayGain(ctx context.Context, results []batchResult, stdout, stderr io.Writer) {
+	var order []int
+	albums := map[int][]string{}
+	for _, r := range results {
+		if r.Item.Index == 0 || r.Status != statusSucceeded || r.Result == nil || r.Result.Path == "" || !replayGainSupported(filepath.Ext(r.Result.Path)) {
+			continue
+		}
+		if _, ok := albums[r.Item.Line]; !ok {
+			order = append(order, r.Item.Line)
+		}
+		albums[r.Item.Line] = append(albums[r.Item.Line], r.Result.Path)
+	}
+
+	for _, line := range order {
+		paths := albums[line]
+		if len(paths) < 2 {
+			continue
+		}
+		gain, err := measureReplayGain(ctx, paths...)
+		if err != nil {
+			fmt.Fprintf(stderr, "Warning: failed to measure album ReplayGain (%v)\n", err)
+			continue
+		}
+		for _, path := range paths {
+			if err := writeReplayGainTags(ctx, path, nil, &gain); err != nil {
+				fmt.Fprintf(stderr, "Warning: failed to write album ReplayGain tags for %s (%v)\n", filepath.Base(path), err)
+			}
+		}
+		fmt.Fprintf(stdout, "Album ReplayGain: %.2f dB across %d tracks\n", gain.Gain, len(paths))
+	}
+}
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReplayGainFromStats(t *testing.T) {
+	gain, err := replayGainFromStats(loudnessStats{InputI: "-9.87", InputTP: "0.42"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	trackGain, peak := formatReplayGain(gain)
+	if trackGain != "-8.13 dB" || peak != "1.049542" {
+		t.Fatalf("got gain %q, peak %q", trackGain, peak)
+	}
+
+	gain, err = replayGainFromStats(loudnessStats{InputI: "-24.5", InputTP: "-6.02"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if trackGain, peak := formatReplayGain(gain); trackGain != "6.50 dB" || peak != "0.500035" {
+		t.Fatalf("got gain %q, peak %q", trackGain, peak)
+	}
+
+	if _, err := replayGainFromStats(loudnessStats{InputI: "-9.87", InputTP: "n/a"}); err == nil {
+		t.Fatalf("expected error for invalid true peak")
+	}
+}
+
+func TestReplayGainArgs(t *testing.T) {
+	track := &replayGain{Gain: -8.13, Peak: 1.049542}
+	album := &replayGain{Gain: -7.5, Peak: 1.1}
+
+	tests := []struct {
+		ext   string
+		album *replayGain
+		want  string
+	}{
+		{".mp3", nil, "-id3v2_version 3 -metadata REPLAYGAIN_TRACK_GAIN=-8.13 dB -metadata REPLAYGAIN_TRACK_PEAK=1.049542"},
+		{".flac", album, "-metadata REPLAYGAIN_TRACK_GAIN=-8.13 dB -metadata REPLAYGAIN_TRACK_PEAK=1.049542 -metadata REPLAYGAIN_ALBUM_GAIN=-7.50 dB -metadata REPLAYGAIN_ALBUM_PEAK=1.100000"},
+		{".opus", nil, "-metadata:s:a:0 REPLAYGAIN_TRACK_GAIN=-8.13 dB -metadata:s:a:0 REPLAYGAIN_TRACK_PEAK=1.049542"},
+	}
+	for _, tc := range tests {
+		if got := strings.Join(replayGainArgs(replayGainTags(track, tc.album), tc.ext), " "); got != tc.want {
+			t.Fatalf("%s: got %q, want %q", tc.ext, got, tc.want)
+		}
+	}
+
+	if got := strings.Join(replayGainArgs(replayGainTags(nil, album), ".flac"), " "); got != "-metadata REPLAYGAIN_ALBUM_GAIN=-7.50 dB -metadata REPLAYGAIN_ALBUM_PEAK=1.100000" {
+		t.Fatalf("album only: got %q", got)
+	}
+}
+
+func TestParseConfigReplayGain(t *testing.T) {
+	cfg, _, err := parseConfig([]string{"--mode", "audio", "--replaygain", "https://youtu.be/example"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.ReplayGain || archiveVariant(cfg) != "audio+replaygain" {
+		t.Fatalf("unexpected config: %+v (variant %q)", cfg, archiveVariant(cfg))
+	}
+
+	expectParseError(t, "only supported with --mode audio", "--replaygain", "https://youtu.be/example")
+	expectParseError(t, "no ReplayGain fields", "--mode", "audio", "--audio-format", "wav", "--replaygain", "https://youtu.be/example")
+	expectParseError(t, "replacing their iTunes tags", "--mode", "audio", "--audio-format", "m4a", "--replaygain", "https://youtu.be/example")
+}
+
+// taggingFFmpeg models the tag handling of an ffmpeg remux: the output gets
+// the input's tag lines unless -map_metadata -1 drops them, followed by the
+// -metadata values. use_metadata_tags turns the kept iTunes tags into mdta
+// keys. Analysis passes print a loudnorm measurement.
+const taggingFFmpeg = `in=""
+prev=""
+drop=""
+mdta=""
+tags=""
+for arg in "$@"; do
+	case "$prev" in
+	-i) [ -z "$in" ] && in=$arg ;;
+	-map_metadata) [ "$arg" = "-1" ] && drop=1 ;;
+	-movflags) [ "$arg" = "use_metadata_tags" ] && mdta=1 ;;
+	-metadata|-metadata:s:a:0) tags="$tags$arg
+" ;;
+	esac
+	prev=$arg
+	out=$arg
+done
+if [ "$out" = "-" ]; then
+	echo '{"input_i": "-9.87", "input_tp": "0.42", "input_lra": "5.10", "input_thresh": "-20.02", "target_offset": "0.03"}' >&2
+	exit 0
+fi
+if [ -n "$drop" ]; then
+	: > "$out"
+elif [ -n "$mdta" ]; then
+	sed 's/^/mdta:/' "$in" > "$out"
+else
+	cp "$in" "$out"
+fi
+printf '%s' "$tags" >> "$out"
+`
+
+func TestRunReplayGainKeepsTags(t *testing.T) {
+	for _, ext := range []string{".mp3", ".flac", ".m4a"} {
+		t.Run(ext, func(t *testing.T) {
+			f := useFakeRunner(t)
+			file := filepath.Join(t.TempDir(), "Daft Punk - One More Time"+ext)
+			f.ytDlp(t, testVideoInfo, file)
+			f.tool(t, "ffmpeg", taggingFFmpeg)
+
+			result, _, _, err := runGet(t, "--mode", "audio", "--audio-format", "best", "--no-cover", "--replaygain")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tags := strings.Split(strings.TrimSpace(string(data)), "\n")
+			if len(tags) < 2 || !strings.EqualFold(tags[0], "artist=Daft Punk") || !strings.EqualFold(tags[1], "title=One More Time") {
+				t.Fatalf("tags were lost: %q", tags)
+			}
+
+			hasGain := strings.Contains(string(data), "REPLAYGAIN_TRACK_GAIN=-8.13 dB\n")
+			if ext == ".m4a" {
+				if hasGain || len(result.Warnings) != 1 || result.Warnings[0] != "ReplayGain tags cannot be written to m4a files, skipping Daft Punk - One More Time.m4a" {
+					t.Fatalf("m4a should be skipped with a warning, got tags %q, warnings %q", tags, result.Warnings)
+				}
+				return
+			}
+			if !hasGain || len(result.Warnings) != 0 {
+				t.Fatalf("expected ReplayGain tags, got tags %q, warnings %q", tags, result.Warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+type errorKind string
+
+const (
+	errKindUsage       errorKind = "usage"
+	errKindDependency  errorKind = "dependency"
+	errKindConfig      errorKind = "config"
+	errKindDownload    errorKind = "download"
+	errKindImport      errorKind = "import"
+	errKindPostprocess errorKind = "postprocess"
+	errKindInternal    errorKind = "internal"
+	errKindInterrupted errorKind = "interrupted"
+)
+
+// kindError tags an error with the category reported in --json output.
+type kindError struct {
+	Kind errorKind
+	Err  error
+}
+
+func (e *kindError) Error() string { return e.Err.Error() }
+
+func (e *kindError) Unwrap() error { return e.Err }
+
+func withKind(kind errorKind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &kindError{Kind: kind, Err: err}
+}
+
+func kindOf(err error) errorKind {
+	var ke *kindError
+	if errors.As(err, &ke) {
+		return ke.Kind
+	}
+	return errKindInternal
+}
+
+type resultStatus string
+
+const (
+	statusSucceeded resultStatus = "succeeded"
+	statusSkipped   resultStatus = "skipped"
+	statusFailed    resultStatus = "failed"
+)
+
+// downloadResult is the structured outcome of one `get` download, printed
+// as JSON with --json.
+type downloadResult struct {
+	URL        string       `json:"url"`
+	Mode       string       `json:"mode"`
+	Status     resultStatus `json:"status"`
+	Path       string       `json:"path,omitempty"`
+	Artist     string       `json:"artist,omitempty"`
+	Title      string       `json:"title,omitempty"`
+	Files      []string     `json:"files,omitempty"`
+	Subtitles  []string     `json:"subtitles,omitempty"`
+	Clip       *clipRange   `json:"clip,omitempty"`
+	Commands   []string     `json:"commands,omitempty"`
+	AppleMusic string       `json:"apple_music_import"`
+	Warnings   []string     `json:"warnings"`
+	SkipReason string       `json:"skip_reason,omitempty"`
+	Error      string       `json:"error,omitempty"`
+	ErrorKind  errorKind    `json:"error_kind,omitempty"`
+}
+
+func newDownloadResult(cfg config) *downloadResult {
+	result := &downloadResult{
+		URL:        cfg.URL,
+		Mode:       cfg.Mode,
+		AppleMusic: "not_requested",
+		Warnings:   []string{},
+	}
+	if cfg.Start != "" || cfg.End != "" {
+		result.Clip = &clipRange{Start: cfg.Start, End: cfg.End, Label: cfg.ClipLabel}
+	}
+	if cfg.AppleMusic {
+		result.AppleMusic = "pending"
+	}
+	return result
+}
+
+// warn prints a warning for humans and records it for --json output.
+func (r *downloadResult) warn(stderr io.Writer, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	r.Warnings = append(r.Warnings, message)
+	fmt.Fprintf(stderr, "Warning: %s\n", message)
+}
+
+func (r *downloadResult) setMetadata(meta *trackMetadata) {
+	if meta == nil {
+		return
+	}
+	r.Artist = meta.Artist
+	r.Title = meta.Title
+}
+
+// finish records the final status for err, which may be nil.
+func (r *downloadResult) finish(err error) {
+	switch {
+	case err == nil:
+		r.Status = statusSucceeded
+	case errors.Is(err, errAlreadyDownloaded):
+		r.Status = statusSkipped
+		r.SkipReason = err.Error()
+	default:
+		r.Status = statusFailed
+		r.Error = err.Error()
+		r.ErrorKind = kindOf(err)
+	}
+	if r.AppleMusic == "pending" {
+		r.AppleMusic = "not_attempted"
+	}
+}
+
+func failedResult(cfg config, err error) *downloadResult {
+	result := newDownloadResult(cfg)
+	result.finish(err)
+	return result
+}
+
+func skippedResult(cfg config, reason string) *downloadResult {
+	result := newDownloadResult(cfg)
+	result.Status = statusSkipped
+	result.SkipReason = reason
+	if result.AppleMusic == "pending" {
+		result.AppleMusic = "not_attempted"
+	}
+	return result
+}
+
+type batchReport struct {
+	Succeeded int               `json:"succeeded"`
+	Skipped   int               `json:"skipped"`
+	Failed    int               `json:"failed"`
+	Results   []*downloadResult `json:"results"`
+}
+
+func newBatchReport(results []batchResult) batchReport {
+	report := batchReport{Results: make([]*downloadResult, 0, len(results))}
+	for _, r := range results {
+		switch r.Status {
+		case statusSucceeded:
+			report.Succeeded++
+		case statusSkipped:
+			report.Skipped++
+		case statusFailed:
+			report.Failed++
+		}
+		report.Results = append(report.Results, r.Result)
+	}
+	return report
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDownloadResultFinish(t *testing.T) {
+	cfg := config{URL: "https://youtu.be/abc", Mode: "audio", Start: "00:00:30", AppleMusic: true}
+
+	result := newDownloadResult(cfg)
+	var stderr bytes.Buffer
+	result.warn(&stderr, "metadata parsing failed (%s)", "boom")
+	result.finish(withKind(errKindDownload, fmt.Errorf("download failed: exit status 1")))
+
+	if result.Status != statusFailed || result.ErrorKind != errKindDownload {
+		t.Fatalf("unexpected status/kind: %s/%s", result.Status, result.ErrorKind)
+	}
+	if result.AppleMusic != "not_attempted" {
+		t.Fatalf("apple music status got %q", result.AppleMusic)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(stderr.String(), "Warning: metadata parsing failed (boom)") {
+		t.Fatalf("warning not recorded: %v / %q", result.Warnings, stderr.String())
+	}
+	if result.Clip == nil || result.Clip.Start != "00:00:30" {
+		t.Fatalf("unexpected clip: %+v", result.Clip)
+	}
+
+	skipped := newDownloadResult(cfg)
+	skipped.finish(fmt.Errorf("%w: /music/a.mp3", errAlreadyDownloaded))
+	if skipped.Status != statusSkipped || skipped.ErrorKind != "" {
+		t.Fatalf("unexpected skipped result: %+v", skipped)
+	}
+}
+
+func TestKindOf(t *testing.T) {
+	wrapped := fmt.Errorf("outer: %w", withKind(errKindImport, errors.New("osascript failed")))
+	if kindOf(wrapped) != errKindImport {
+		t.Fatalf("kind got %q", kindOf(wrapped))
+	}
+	if kindOf(errors.New("plain")) != errKindInternal {
+		t.Fatal("expected internal kind for untagged errors")
+	}
+}
+
+func TestGetJSONReportsUsageErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"--json", "--start", "bogus", "https://youtu.be/abc"}, &stdout, &stderr)
+	if code != 2 {
+		t.Fatalf("exit code %d, want 2", code)
+	}
+
+	var result downloadResult
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("stdout is not a JSON result: %v\n%s", err, stdout.String())
+	}
+	if result.Status != statusFailed || result.ErrorKind != errKindUsage {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if !strings.Contains(stderr.String(), "Usage:") {
+		t.Fatal("expected usage text on stderr")
+	}
+}
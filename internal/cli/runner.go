@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// terminateGrace is how long a program gets to exit after ytcli asked it to
+// stop before it is killed.
+const terminateGrace = 5 * time.Second
+
+// commandRunner starts external programs. Every yt-dlp, ffmpeg and osascript
+// invocation goes through runner, so tests can swap in fake executables.
+type commandRunner interface {
+	// LookPath searches for an executable like exec.LookPath.
+	LookPath(name string) (string, error)
+	// Command returns the command that runs name with args. When ctx is
+	// cancelled the program is asked to terminate.
+	Command(ctx context.Context, name string, args ...string) *exec.Cmd
+}
+
+// execRunner runs the real programs found in PATH.
+type execRunner struct{}
+
+func (execRunner) LookPath(name string) (string, error) {
+	return exec.LookPath(name)
+}
+
+func (execRunner) Command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	// SIGTERM rather than the default kill lets yt-dlp and ffmpeg close
+	// their files; whatever is still running after the grace period is
+	// killed. Where SIGTERM cannot be sent (Windows) the process is killed
+	// right away.
+	cmd.Cancel = func() error {
+		if err := cmd.Process.Signal(syscall.SIGTERM); err != nil && err != os.ErrProcessDone {
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+	cmd.WaitDelay = terminateGrace
+	return cmd
+}
+
+// runner is the commandRunner used for all external programs.
+var runner commandRunner = execRunner{}
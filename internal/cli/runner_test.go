@@ -0,0 +1,318 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testVideoURL = "https://youtu.be/u9oxz7AQg5c"
+
+const testVideoInfo = `{"id": "u9oxz7AQg5c", "title": "Daft Punk - One More Time (Official Video)", "uploader": "Daft Punk", "artist": "Daft Punk", "track": "One More Time", "webpage_url": "https://www.youtube.com/watch?v=u9oxz7AQg5c"}`
+
+// copyingFFmpeg copies its first input to its last argument, which is all
+// a tagging pass needs to look successful.
+const copyingFFmpeg = `in=""
+prev=""
+for arg in "$@"; do
+	if [ "$prev" = "-i" ] && [ -z "$in" ]; then
+		in=$arg
+	fi
+	prev=$arg
+	out=$arg
+done
+cp "$in" "$out"
+`
+
+// fakeRunner runs shell scripts from dir in place of the real programs and
+// records every command it starts. Batch workers share it, so calls is
+// guarded by mu.
+type fakeRunner struct {
+	dir   string
+	mu    sync.Mutex
+	calls [][]string
+}
+
+func (f *fakeRunner) LookPath(name string) (string, error) {
+	path := filepath.Join(f.dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", &exec.Error{Name: name, Err: exec.ErrNotFound}
+	}
+	return path, nil
+}
+
+func (f *fakeRunner) Command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	f.mu.Lock()
+	f.calls = append(f.calls, append([]string{filepath.Base(name)}, args...))
+	f.mu.Unlock()
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(f.dir, name)
+	}
+	return execRunner{}.Command(ctx, name, args...)
+}
+
+// callsTo returns the command lines recorded for the program name.
+func (f *fakeRunner) callsTo(name string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var lines []string
+	for _, call := range f.calls {
+		if call[0] == name {
+			lines = append(lines, shellQuote(call))
+		}
+	}
+	return lines
+}
+
+// tool installs a fake program that runs script with sh.
+func (f *fakeRunner) tool(t *testing.T, name, script string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(f.dir, name), []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// ytDlp installs a fake yt-dlp. It prints info for --dump-single-json, or
+// fails when info is empty. A download creates file with a thumbnail next to
+// it and reports it as the final path; with an empty file nothing is
+// reported.
+func (f *fakeRunner) ytDlp(t *testing.T, info, file string) {
+	t.Helper()
+	dump := "echo 'ERROR: [youtube] u9oxz7AQg5c: Video unavailable' >&2\n\texit 1"
+	if info != "" {
+		dump = "cat <<'EOF'\n" + info + "\nEOF"
+	}
+	download := "echo '[download] Destination: unknown'"
+	if file != "" {
+		download = ": > " + shellQuote([]string{file}) + "\n\t: > " + shellQuote([]string{thumbnailPath(file)}) +
+			"\n\techo " + shellQuote([]string{finalPathPrefix + file})
+	}
+	f.tool(t, "yt-dlp", `case " $* " in
+*" --dump-single-json "*)
+	`+dump+`
+	;;
+*)
+	`+download+`
+	;;
+esac
+`)
+}
+
+func useFakeRunner(t *testing.T) *fakeRunner {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake tools are shell scripts")
+	}
+	f := &fakeRunner{dir: t.TempDir()}
+	previous := runner
+	runner = f
+	t.Cleanup(func() { runner = previous })
+	return f
+}
+
+// runGet runs a single download of testVideoURL with args against the fake
+// tools.
+func runGet(t *testing.T, args ...string) (*downloadResult, string, string, error) {
+	t.Helper()
+	cfg, _, err := parseConfig(append(append([]string{"--no-archive"}, args...), testVideoURL), &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected config error: %v", err)
+	}
+	var stdout, stderr bytes.Buffer
+	result, err := run(context.Background(), cfg, &stdout, &stderr)
+	return result, stdout.String(), stderr.String(), err
+}
+
+func assertNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+	leftovers, _ := filepath.Glob(filepath.Join(dir, "*.ytcli-*"))
+	if len(leftovers) != 0 {
+		t.Fatalf("temporary files left behind: %v", leftovers)
+	}
+}
+
+func TestRunTagsAudioDownload(t *testing.T) {
+	f := useFakeRunner(t)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "Daft Punk - One More Time.mp3")
+	f.ytDlp(t, testVideoInfo, file)
+	f.tool(t, "ffmpeg", copyingFFmpeg)
+
+	result, stdout, _, err := runGet(t, "--mode", "audio")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != statusSucceeded || result.Path != file || result.Artist != "Daft Punk" || result.Title != "One More Time" || len(result.Warnings) != 0 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if !strings.Contains(stdout, "Tagged audio metadata: Daft Punk - One More Time") || !strings.Contains(stdout, "Embedded cover art: Daft Punk - One More Time.jpg") {
+		t.Fatalf("unexpected output: %q", stdout)
+	}
+
+	tagging := f.callsTo("ffmpeg")
+	if len(tagging) != 1 || !strings.Contains(tagging[0], "-metadata 'artist=Daft Punk' -metadata 'title=One More Time'") || !strings.Contains(tagging[0], "-metadata 'comment=https://www.youtube.com/watch?v=u9oxz7AQg5c'") {
+		t.Fatalf("unexpected ffmpeg calls: %v", tagging)
+	}
+	if _, err := os.Stat(thumbnailPath(file)); !os.IsNotExist(err) {
+		t.Fatalf("thumbnail was not removed: %v", err)
+	}
+	assertNoTempFiles(t, dir)
+}
+
+func TestRunMetadataFetchFailure(t *testing.T) {
+	f := useFakeRunner(t)
+	file := filepath.Join(t.TempDir(), "Daft Punk - One More Time.mp3")
+	f.ytDlp(t, "", file)
+	f.tool(t, "ffmpeg", copyingFFmpeg)
+
+	result, _, stderr, err := runGet(t, "--mode", "audio", "--no-cover")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Warnings) != 2 ||
+		!strings.HasPrefix(result.Warnings[0], "metadata parsing failed, using yt-dlp artist/title fallback template (failed to fetch video info:") ||
+		result.Warnings[1] != "metadata fetch failed, inferred tags from filename: Daft Punk - One More Time" {
+		t.Fatalf("unexpected warnings: %q", result.Warnings)
+	}
+	if !strings.Contains(stderr, "Warning: metadata fetch failed") {
+		t.Fatalf("warning not printed: %q", stderr)
+	}
+	if result.Artist != "Daft Punk" || result.Title != "One More Time" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	tagging := f.callsTo("ffmpeg")
+	if len(tagging) != 1 || !strings.Contains(tagging[0], "-metadata 'title=One More Time'") {
+		t.Fatalf("unexpected ffmpeg calls: %v", tagging)
+	}
+}
+
+func TestRunMissingFinalPath(t *testing.T) {
+	f := useFakeRunner(t)
+	f.ytDlp(t, testVideoInfo, "")
+	f.tool(t, "ffmpeg", copyingFFmpeg)
+
+	result, stdout, _, err := runGet(t, "--mode", "audio")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != statusSucceeded || result.Path != "" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0] != "download completed but output path was unavailable, skipping metadata tagging" {
+		t.Fatalf("unexpected warnings: %q", result.Warnings)
+	}
+	if calls := f.callsTo("ffmpeg"); len(calls) != 0 {
+		t.Fatalf("ffmpeg should not run without a path: %v", calls)
+	}
+	if !strings.Contains(stdout, "Download completed successfully.") {
+		t.Fatalf("unexpected output: %q", stdout)
+	}
+}
+
+func TestRunTaggingFailure(t *testing.T) {
+	f := useFakeRunner(t)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "Daft Punk - One More Time.mp3")
+	f.ytDlp(t, testVideoInfo, file)
+	f.tool(t, "ffmpeg", "echo 'Daft Punk - One More Time.mp3: Invalid data found when processing input' >&2\nexit 1\n")
+
+	result, _, _, err := runGet(t, "--mode", "audio", "--no-cover")
+	if err != nil {
+		t.Fatalf("tagging failures should only warn, got %v", err)
+	}
+	want := "failed to write audio metadata tags (ffmpeg metadata write failed: Daft Punk - One More Time.mp3: Invalid data found when processing input)"
+	if result.Status != statusSucceeded || len(result.Warnings) != 1 || result.Warnings[0] != want {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if _, err := os.Stat(file); err != nil {
+		t.Fatalf("download was lost: %v", err)
+	}
+	assertNoTempFiles(t, dir)
+}
+
+func TestRunDownloadFailure(t *testing.T) {
+	f := useFakeRunner(t)
+	f.tool(t, "yt-dlp", "echo 'ERROR: [youtube] u9oxz7AQg5c: Sign in to confirm your age' >&2\nexit 1\n")
+
+	result, _, stderr, err := runGet(t, "--mode", "video")
+	if err == nil || kindOf(err) != errKindDownload || !strings.HasPrefix(err.Error(), "download failed:") {
+		t.Fatalf("expected download error, got %v", err)
+	}
+	if result.Status != statusFailed || result.ErrorKind != errKindDownload {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if !strings.Contains(stderr, "Sign in to confirm your age") {
+		t.Fatalf("yt-dlp error not passed through: %q", stderr)
+	}
+
+	f = useFakeRunner(t)
+	if _, _, _, err := runGet(t, "--mode", "video"); err == nil || kindOf(err) != errKindDependency {
+		t.Fatalf("expected dependency error without yt-dlp, got %v", err)
+	}
+}
+
+func TestRunImportErrors(t *testing.T) {
+	t.Run("missing path", func(t *testing.T) {
+		f := useFakeRunner(t)
+		f.ytDlp(t, testVideoInfo, "")
+		f.tool(t, "ffmpeg", copyingFFmpeg)
+
+		result, _, _, err := runGet(t, "--mode", "audio", "--apple-music")
+		if err == nil || kindOf(err) != errKindImport || err.Error() != "download completed but could not determine output path for Apple Music import" {
+			t.Fatalf("expected import error, got %v", err)
+		}
+		if result.AppleMusic != "failed" || result.ErrorKind != errKindImport {
+			t.Fatalf("unexpected result: %+v", result)
+		}
+	})
+
+	t.Run("osascript fails", func(t *testing.T) {
+		f := useFakeRunner(t)
+		file := filepath.Join(t.TempDir(), "Daft Punk - One More Time.mp3")
+		f.ytDlp(t, testVideoInfo, file)
+		f.tool(t, "ffmpeg", copyingFFmpeg)
+		f.tool(t, "osascript", "echo 'execution error: Music got an error: File permission error. (-54)' >&2\nexit 1\n")
+
+		want := "failed to import into Apple Music: execution error: Music got an error: File permission error. (-54)"
+		if runtime.GOOS != "darwin" {
+			want = "--apple-music is only supported on macOS"
+		}
+		result, _, _, err := runGet(t, "--mode", "audio", "--no-cover", "--apple-music")
+		if err == nil || kindOf(err) != errKindImport || err.Error() != want {
+			t.Fatalf("expected %q, got %v", want, err)
+		}
+		if result.AppleMusic != "failed" || result.Path != file || result.Title != "One More Time" {
+			t.Fatalf("unexpected result: %+v", result)
+		}
+		if len(f.callsTo("ffmpeg")) != 1 {
+			t.Fatalf("file should be tagged before the import: %v", f.calls)
+		}
+	})
+}
+
+func TestRunBatchParallelDownloads(t *testing.T) {
+	f := useFakeRunner(t)
+	f.ytDlp(t, testVideoInfo, "")
+
+	cfg, _, err := parseConfig([]string{"--no-archive", "--mode", "video", "--jobs", "3", testVideoURL}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected config error: %v", err)
+	}
+	items := []batchItem{{Line: 1, URL: "https://youtu.be/a"}, {Line: 2, URL: "https://youtu.be/b"}, {Line: 3, URL: "https://youtu.be/c"}}
+	var stdout bytes.Buffer
+	results := runBatch(context.Background(), cfg, items, &stdout, &bytes.Buffer{})
+	for _, r := range results {
+		if r.Status != statusSucceeded {
+			t.Fatalf("unexpected result: %+v", r)
+		}
+	}
+	if calls := f.callsTo("yt-dlp"); len(calls) != len(items) {
+		t.Fatalf("expected one yt-dlp call per item, got %v", calls)
+	}
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// reSubLang matches one entry of a --subs list: a language code, "all", or
+// a yt-dlp language regex such as "en.*".
+var reSubLang = regexp.MustCompile(`^-?[A-Za-z0-9_.*+-]+$`)
+
+func validateSubtitleOptions(cfg config) error {
+	if cfg.Subs == "" {
+		if cfg.AutoSubs || cfg.EmbedSubs {
+			return fmt.Errorf("--auto-subs and --embed-subs require --subs LANGS")
+		}
+		return nil
+	}
+	for _, lang := range strings.Split(cfg.Subs, ",") {
+		if !reSubLang.MatchString(strings.TrimSpace(lang)) {
+			return fmt.Errorf("invalid subtitle language %q in --subs; use a comma-separated list such as en,de", lang)
+		}
+	}
+	if cfg.Mode == "audio" {
+		return fmt.Errorf("--subs is only supported with --mode video or full")
+	}
+	if cfg.EmbedSubs && cfg.container() == "webm" {
+		return fmt.Errorf("--embed-subs requires --container mp4 or mkv; webm cannot hold srt subtitles")
+	}
+	if cfg.Split {
+		return fmt.Errorf("--subs cannot be combined with --split-chapters")
+	}
+	return nil
+}
+
+// subtitleArgs asks yt-dlp for the requested subtitles as SRT, either
+// embedded as soft tracks or written next to the download.
+func subtitleArgs(cfg config) []string {
+	if cfg.Subs == "" {
+		return nil
+	}
+	args := []string{"--write-subs"}
+	if cfg.AutoSubs {
+		// Manual subtitles still win for languages that have both.
+		args = append(args, "--write-auto-subs")
+	}
+	args = append(args, "--sub-langs", strings.ReplaceAll(cfg.Subs, " ", ""), "--convert-subs", "srt")
+	if cfg.EmbedSubs {
+		args = append(args, "--embed-subs")
+	}
+	return args
+}
+
+// collectSubtitles records the sidecar subtitles yt-dlp saved next to the
+// download.
+func collectSubtitles(ctx context.Context, job *postJob) error {
+	files, err := findSubtitleFiles(job.Path)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no subtitles found for %s", job.Cfg.Subs)
+	}
+	job.Result.Subtitles = files
+	for _, file := range files {
+		fmt.Fprintf(job.Stdout, "Saved subtitles: %s\n", file)
+	}
+	return nil
+}
+
+// findSubtitleFiles lists the sidecar subtitles yt-dlp wrote for the
+// download at path, named "<name>.<lang>.srt".
+func findSubtitleFiles(path string) ([]string, error) {
+	dir := filepath.Dir(path)
+	prefix := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) + "."
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subtitle files: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".srt") {
+			continue
+		}
+		if lang := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".srt"); lang == "" || strings.Contains(lang, ".") {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSubtitleArgs(t *testing.T) {
+	tests := []struct {
+		cfg  config
+		want string
+	}{
+		{config{}, ""},
+		{config{Subs: "en, de"}, "--write-subs --sub-langs en,de --convert-subs srt"},
+		{config{Subs: "en", AutoSubs: true, EmbedSubs: true}, "--write-subs --write-auto-subs --sub-langs en --convert-subs srt --embed-subs"},
+	}
+	for _, tc := range tests {
+		if got := strings.Join(subtitleArgs(tc.cfg), " "); got != tc.want {
+			t.Fatalf("%+v: got %q, want %q", tc.cfg, got, tc.want)
+		}
+	}
+}
+
+func TestFindSubtitleFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"Talk [abc].mp4",
+		"Talk [abc].en.srt",
+		"Talk [abc].de-DE.srt",
+		"Talk [abc].en.vtt",
+		"Talk [abc] part 2.en.srt",
+		"Talk [abc].part2.en.srt",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := findSubtitleFiles(filepath.Join(dir, "Talk [abc].mp4"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{filepath.Join(dir, "Talk [abc].de-DE.srt"), filepath.Join(dir, "Talk [abc].en.srt")}
+	if strings.Join(files, "|") != strings.Join(want, "|") {
+		t.Fatalf("got %v, want %v", files, want)
+	}
+}
+
+func TestParseConfigSubtitles(t *testing.T) {
+	cfg, _, err := parseConfig([]string{"--subs", "en,de", "--auto-subs", "--container", "mkv", "--embed-subs", "https://youtu.be/example"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if archiveVariant(cfg) != "full:mkv+subs" {
+		t.Fatalf("got variant %q", archiveVariant(cfg))
+	}
+
+	expectParseError(t, "require --subs", "--auto-subs", "https://youtu.be/example")
+	expectParseError(t, "only supported with --mode", "--mode", "audio", "--subs", "en", "https://youtu.be/example")
+	expectParseError(t, "webm cannot hold", "--subs", "en", "--container", "webm", "--embed-subs", "https://youtu.be/example")
+	expectParseError(t, "invalid subtitle language", "--subs", "en,,de", "https://youtu.be/example")
+	expectParseError(t, "cannot be combined with --split", "--subs", "en", "--split-chapters", "https://youtu.be/example")
+}
@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var reYear = regexp.MustCompile(`^\d{4}(-\d{2}-\d{2})?$`)
+
+// audioFormats are the accepted --audio-format values. "best" lets yt-dlp
+// keep the source audio stream without re-encoding.
+var audioFormats = []string{"mp3", "m4a", "opus", "flac", "wav", "best"}
+
+// appleMusicFormats are the audio formats Music.app can import.
+var appleMusicFormats = map[string]bool{"mp3": true, "m4a": true, "wav": true}
+
+func validateAudioFormat(format string) error {
+	for _, f := range audioFormats {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid audio format %q; expected %s", format, strings.Join(audioFormats, ", "))
+}
+
+// audioFormat returns the --audio-format value, defaulting to mp3.
+func (c config) audioFormat() string {
+	if c.AudioFormat == "" {
+		return "mp3"
+	}
+	return c.AudioFormat
+}
+
+// bestAudioExtension predicts the extension yt-dlp gives the highest bitrate
+// audio-only format when extracting it without re-encoding.
+func bestAudioExtension(formats []videoFormat) string {
+	var best *videoFormat
+	for i := range formats {
+		f := &formats[i]
+		if f.VCodec != "none" || f.ACodec == "" || f.ACodec == "none" {
+			continue
+		}
+		if best == nil || f.Bitrate > best.Bitrate {
+			best = f
+		}
+	}
+	if best == nil {
+		return "NA"
+	}
+
+	codecs := map[string]string{"opus": "opus", "vorbis": "ogg", "mp4a": "m4a", "mp3": "mp3", "flac": "flac"}
+	for prefix, ext := range codecs {
+		if strings.HasPrefix(best.ACodec, prefix) {
+			return ext
+		}
+	}
+	return best.Ext
+}
+
+// metadataArgs returns the ffmpeg options that write meta into a file with
+// extension ext. Artist and title are always written; the other fields only
+// when known. ffmpeg's generic keys map cleanly onto ID3 and MP4 atoms, but
+// Vorbis comments and RIFF INFO need their own field names and layout.
+func metadataArgs(meta trackMetadata, ext string) []string {
+	type field struct{ key, value string }
+	var fields []field
+	var args []string
+	option := "-metadata"
+
+	switch strings.ToLower(strings.TrimPrefix(ext, ".")) {
+	case "opus", "ogg", "oga", "flac":
+		fields = []field{{"ARTIST", meta.Artist}, {"TITLE", meta.Title}, {"ALBUM", meta.Album}, {"ALBUMARTIST", meta.AlbumArtist}}
+		if meta.Track > 0 {
+			fields = append(fields, field{"TRACKNUMBER", strconv.Itoa(meta.Track)})
+		}
+		if meta.TrackTotal > 0 {
+			fields = append(fields, field{"TRACKTOTAL", strconv.Itoa(meta.TrackTotal)})
+		}
+		fields = append(fields, field{"DATE", meta.Date}, field{"GENRE", meta.Genre}, field{"COMMENT", meta.Comment})
+		if !strings.EqualFold(strings.TrimPrefix(ext, "."), "flac") {
+			// Ogg keeps its comment header on the audio stream.
+			option = "-metadata:s:a:0"
+		}
+	case "wav":
+		// RIFF INFO has no album artist or track total.
+		fields = []field{{"artist", meta.Artist}, {"title", meta.Title}, {"album", meta.Album}}
+		if meta.Track > 0 {
+			fields = append(fields, field{"track", strconv.Itoa(meta.Track)})
+		}
+		fields = append(fields, field{"date", meta.Date}, field{"genre", meta.Genre}, field{"comment", meta.Comment})
+	default:
+		fields = []field{{"artist", meta.Artist}, {"title", meta.Title}, {"album", meta.Album}, {"album_artist", meta.AlbumArtist}}
+		if meta.Track > 0 {
+			track := strconv.Itoa(meta.Track)
+			if meta.TrackTotal > 0 {
+				track += "/" + strconv.Itoa(meta.TrackTotal)
+			}
+			fields = append(fields, field{"track", track})
+		}
+		fields = append(fields, field{"date", meta.Date}, field{"genre", meta.Genre}, field{"comment", meta.Comment})
+		if strings.EqualFold(ext, ".mp3") {
+			// ID3v2.3 is the newest version every common player reads;
+			// ffmpeg splits a full date into its TYER and TDAT frames.
+			args = append(args, "-id3v2_version", "3")
+		}
+	}
+
+	for i, f := range fields {
+		// Artist and title are written even when empty so stale tags from
+		// the source are replaced.
+		if f.value == "" && i >= 2 {
+			continue
+		}
+		args = append(args, option, f.key+"="+f.value)
+	}
+	return args
+}
+
+// applyTagOverrides replaces the album, date and genre tags of meta with the
+// non-empty overrides.
+func applyTagOverrides(meta *trackMetadata, album, year, genre string) {
+	if strings.TrimSpace(album) != "" {
+		meta.Album = strings.TrimSpace(album)
+	}
+	if strings.TrimSpace(year) != "" {
+		meta.Date = strings.TrimSpace(year)
+	}
+	if strings.TrimSpace(genre) != "" {
+		meta.Genre = strings.TrimSpace(genre)
+	}
+}
+
+func validateYear(year string) error {
+	if year != "" && !reYear.MatchString(strings.TrimSpace(year)) {
+		return fmt.Errorf("invalid year %q; use YYYY or YYYY-MM-DD", year)
+	}
+	return nil
+}
+
+// formatExtraTags summarizes the tags beyond artist and title, e.g.
+// "album=Discovery, track=1, date=2001-03-12".
+func formatExtraTags(meta trackMetadata) string {
+	var parts []string
+	add := func(name, value string) {
+		if value != "" {
+			parts = append(parts, name+"="+value)
+		}
+	}
+	add("album", meta.Album)
+	add("album_artist", meta.AlbumArtist)
+	if meta.Track > 0 {
+		add("track", strconv.Itoa(meta.Track))
+	}
+	add("date", meta.Date)
+	add("genre", meta.Genre)
+	add("comment", meta.Comment)
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMetadataArgs(t *testing.T) {
+	basic := trackMetadata{Artist: "A", Title: "T"}
+	full := trackMetadata{Artist: "A", Title: "T", Album: "L", AlbumArtist: "B", Track: 3, TrackTotal: 9}
+
+	tests := []struct {
+		name string
+		meta trackMetadata
+		ext  string
+		want string
+	}{
+		{"mp3 basic", basic, ".mp3", "-id3v2_version 3 -metadata artist=A -metadata title=T"},
+		{"mp3 album", full, ".mp3", "-id3v2_version 3 -metadata artist=A -metadata title=T -metadata album=L -metadata album_artist=B -metadata track=3/9"},
+		{"m4a", full, ".m4a", "-metadata artist=A -metadata title=T -metadata album=L -metadata album_artist=B -metadata track=3/9"},
+		{"opus", full, ".opus", "-metadata:s:a:0 ARTIST=A -metadata:s:a:0 TITLE=T -metadata:s:a:0 ALBUM=L -metadata:s:a:0 ALBUMARTIST=B -metadata:s:a:0 TRACKNUMBER=3 -metadata:s:a:0 TRACKTOTAL=9"},
+		{"flac", full, ".FLAC", "-metadata ARTIST=A -metadata TITLE=T -metadata ALBUM=L -metadata ALBUMARTIST=B -metadata TRACKNUMBER=3 -metadata TRACKTOTAL=9"},
+		{"wav", full, ".wav", "-metadata artist=A -metadata title=T -metadata album=L -metadata track=3"},
+		{"empty artist", trackMetadata{Title: "T"}, ".m4a", "-metadata artist= -metadata title=T"},
+	}
+
+	for _, tc := range tests {
+		got := strings.Join(metadataArgs(tc.meta, tc.ext), " ")
+		if got != tc.want {
+			t.Fatalf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestBestAudioExtension(t *testing.T) {
+	formats := []videoFormat{
+		{ID: "140", Ext: "m4a", ACodec: "mp4a.40.2", VCodec: "none", Bitrate: 129},
+		{ID: "251", Ext: "webm", ACodec: "opus", VCodec: "none", Bitrate: 135},
+		{ID: "18", Ext: "mp4", ACodec: "mp4a.40.2", VCodec: "avc1.42001E", Bitrate: 500},
+	}
+	if got := bestAudioExtension(formats); got != "opus" {
+		t.Fatalf("got %q, want opus", got)
+	}
+	if got := bestAudioExtension(formats[:1]); got != "m4a" {
+		t.Fatalf("got %q, want m4a", got)
+	}
+	if got := bestAudioExtension(formats[2:]); got != "NA" {
+		t.Fatalf("got %q, want NA", got)
+	}
+}
+
+func TestParseConfigAudioFormat(t *testing.T) {
+	cfg, _, err := parseConfig([]string{"--mode", "audio", "--audio-format", "flac", "https://youtu.be/example"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	args, err := buildArgs(cfg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(strings.Join(args, " "), "-x --audio-format flac") {
+		t.Fatalf("missing audio format in %v", args)
+	}
+	if archiveVariant(cfg) != "audio:flac" {
+		t.Fatalf("got variant %q", archiveVariant(cfg))
+	}
+
+	expectParseError(t, "invalid audio format", "--mode", "audio", "--audio-format", "aac", "https://youtu.be/example")
+	expectParseError(t, "only supported with --mode", "--audio-format", "m4a", "https://youtu.be/example")
+	expectParseError(t, "Music.app cannot import opus", "--mode", "audio", "--audio-format", "opus", "--apple-music", "https://youtu.be/example")
+}
+
+func TestMetadataArgsExtendedTags(t *testing.T) {
+	meta := trackMetadata{Artist: "A", Title: "T", Track: 1, Date: "2001-03-12", Genre: "House", Comment: "https://youtu.be/x"}
+
+	tests := []struct {
+		ext  string
+		want string
+	}{
+		{".mp3", "-id3v2_version 3 -metadata artist=A -metadata title=T -metadata track=1 -metadata date=2001-03-12 -metadata genre=House -metadata comment=https://youtu.be/x"},
+		{".flac", "-metadata ARTIST=A -metadata TITLE=T -metadata TRACKNUMBER=1 -metadata DATE=2001-03-12 -metadata GENRE=House -metadata COMMENT=https://youtu.be/x"},
+		{".wav", "-metadata artist=A -metadata title=T -metadata track=1 -metadata date=2001-03-12 -metadata genre=House -metadata comment=https://youtu.be/x"},
+	}
+	for _, tc := range tests {
+		if got := strings.Join(metadataArgs(meta, tc.ext), " "); got != tc.want {
+			t.Fatalf("%s: got %q, want %q", tc.ext, got, tc.want)
+		}
+	}
+}
+
+func TestVideoInfoExtendedTags(t *testing.T) {
+	info, err := parseVideoInfo([]byte(`{
+		"title": "Daft Punk - One More Time",
+		"uploader": "Daft Punk - Topic",
+		"track": "One More Time",
+		"artist": "Daft Punk",
+		"album": "Discovery",
+		"track_number": 1,
+		"genres": ["House", "French house"],
+		"release_year": 2001,
+		"upload_date": "20150801",
+		"webpage_url": "https://www.youtube.com/watch?v=FGBhQbmPwH8"
+	}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	meta, err := info.tagMetadata()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := trackMetadata{
+		Artist:  "Daft Punk",
+		Title:   "One More Time",
+		Album:   "Discovery",
+		Track:   1,
+		Date:    "2001",
+		Genre:   "House",
+		Comment: "https://www.youtube.com/watch?v=FGBhQbmPwH8",
+	}
+	if *meta != want {
+		t.Fatalf("got %+v, want %+v", *meta, want)
+	}
+
+	info.ReleaseDate = "20010312"
+	if got := info.releaseDate(); got != "2001-03-12" {
+		t.Fatalf("release date should win, got %q", got)
+	}
+	info.ReleaseDate, info.ReleaseYear = "", 0
+	if got := info.releaseDate(); got != "2015-08-01" {
+		t.Fatalf("upload date fallback, got %q", got)
+	}
+
+	applyTagOverrides(meta, "Alive 2007", " 2007 ", "")
+	if meta.Album != "Alive 2007" || meta.Date != "2007" || meta.Genre != "House" {
+		t.Fatalf("unexpected overrides: %+v", meta)
+	}
+	if got := formatExtraTags(*meta); got != "album=Alive 2007, track=1, date=2007, genre=House, comment=https://www.youtube.com/watch?v=FGBhQbmPwH8" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestParseConfigTagOverrides(t *testing.T) {
+	cfg, _, err := parseConfig([]string{"--mode", "audio", "--album", "Discovery", "--year", "2001-03-12", "--genre", "House", "https://youtu.be/example"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Album != "Discovery" || cfg.Year != "2001-03-12" || cfg.Genre != "House" {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+
+	expectParseError(t, "invalid year", "--mode", "audio", "--year", "01", "https://youtu.be/example")
+	expectParseError(t, "only supported with --mode audio", "--album", "Discovery", "https://youtu.be/example")
+}